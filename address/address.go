@@ -0,0 +1,86 @@
+// Package address implements the rules shared by the config and the peerstore
+// to validate and normalize peer addresses
+package address
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAddress = errors.New("address: invalid peer address")
+)
+
+const maxHostnameLength = 253
+
+// Normalize validates a peer address and returns its canonical form.
+// Accepted addresses are
+//   - host:port where host is an IP or a hostname and port a number or a service name
+//   - a bare IP
+//   - a URL with a host, e.g. grpc://www.example.com:8000, whose "www." prefix is dropped
+//
+// Hostnames are lowercased, IPs are printed in their canonical form and service names
+// are resolved to their port number, e.g. host:http becomes host:80
+func Normalize(addr string) (string, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String(), nil
+	}
+
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil || u.Host == "" {
+			return "", ErrInvalidAddress
+		}
+		addr = strings.TrimPrefix(u.Host, "www.")
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+	p, err := net.LookupPort("tcp", port)
+	if err != nil || p <= 0 {
+		return "", ErrInvalidAddress
+	}
+	port = strconv.Itoa(p)
+
+	if ip := net.ParseIP(host); ip != nil {
+		return net.JoinHostPort(ip.String(), port), nil
+	}
+	host = strings.ToLower(host)
+	if !validHostname(host) {
+		return "", ErrInvalidAddress
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Valid returns true if the address can be normalized
+func Valid(addr string) bool {
+	_, err := Normalize(addr)
+	return err == nil
+}
+
+// validHostname checks the hostname against the DNS label rules
+func validHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > maxHostnameLength {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
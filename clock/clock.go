@@ -0,0 +1,57 @@
+// Package clock abstracts the source of the current time so that time dependent
+// behaviour such as eviction and record sequence numbers can be controlled in tests
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock returns the current time
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Real is the wall clock
+var Real Clock = realClock{}
+
+// Manual is a clock which only moves when it is set or advanced
+type Manual struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewManual creates a manual clock set to now
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time of the clock
+func (m *Manual) Now() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.now
+}
+
+// Set sets the time of the clock
+func (m *Manual) Set(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.now = now
+}
+
+// Advance moves the clock forward by d
+func (m *Manual) Advance(d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.now = m.now.Add(d)
+}
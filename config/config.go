@@ -1,33 +1,191 @@
 package config
 
-import (
-	"os"
-
-	"gopkg.in/yaml.v2"
-)
+import "time"
 
 type Peer struct {
-	Addr        string `yaml:"addr"`
-	Attributes  map[string]string
-	Name        string `yaml:"name"`
-	ClusterName string `yaml:"clusterName"`
+	Addr        string            `yaml:"addr"`
+	Attributes  map[string]string `yaml:"attributes"`
+	Name        string            `yaml:"name"`
+	ClusterName string            `yaml:"clusterName"`
+}
+
+// TLS contains the certificates used to secure connections between peers.
+// TLS is enabled when both CertFile and KeyFile are set
+type TLS struct {
+	// CAFile is the PEM bundle used to verify the certificates of remote peers
+	CAFile string `yaml:"caFile"`
+	// CertFile is the PEM certificate presented by this node as server and as client
+	CertFile string `yaml:"certFile"`
+	// KeyFile is the PEM private key of CertFile
+	KeyFile string `yaml:"keyFile"`
+	// RequireClientCert rejects incoming connections without a verified client certificate
+	RequireClientCert bool `yaml:"requireClientCert"`
+}
+
+// Enabled reports whether TLS is configured
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Identity contains the location of the node keypair used to sign its peer record
+type Identity struct {
+	// KeyFile is the PEM encoded Ed25519 private key. It is generated if it does not exist.
+	// It defaults to identity.key in the data directory of the store. An ephemeral key,
+	// and so a new peer ID on every start, is only used if neither is set
+	KeyFile string `yaml:"keyFile"`
+}
+
+// Swim contains the parameters of the SWIM membership protocol.
+// Zero values are replaced by defaults
+type Swim struct {
+	// ProbeInterval is the period of a protocol round probing one member
+	ProbeInterval time.Duration `yaml:"probeInterval"`
+	// ProbeTimeout is the time to wait for an ack of a direct or indirect probe
+	ProbeTimeout time.Duration `yaml:"probeTimeout"`
+	// IndirectProbes is the number of members asked to probe a member that did not ack
+	IndirectProbes int `yaml:"indirectProbes"`
+	// SuspicionTimeout is the time a member stays suspect before it is declared dead
+	SuspicionTimeout time.Duration `yaml:"suspicionTimeout"`
+	// RetransmitMult scales the number of times an update is piggybacked
+	RetransmitMult int `yaml:"retransmitMult"`
+}
+
+// Backoff configures the exponential backoff applied to a failing peer
+type Backoff struct {
+	// Initial is the delay after the first failure
+	Initial time.Duration `yaml:"initial"`
+	// Max caps the delay
+	Max time.Duration `yaml:"max"`
+	// Multiplier scales the delay after every consecutive failure
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// Discovery selects and configures the peer discovery strategy.
+// Zero values are replaced by defaults
+type Discovery struct {
+	// Strategy is "scan" (default), "swim" or "kademlia"
+	Strategy string `yaml:"strategy"`
+
+	// Interval is the time between two rounds of the scan strategy
+	Interval time.Duration `yaml:"interval"`
+	// Jitter is the maximum random delay added to Interval
+	Jitter time.Duration `yaml:"jitter"`
+	// FanOut is the number of random peers queried per round. All peers are queried if zero
+	FanOut int `yaml:"fanOut"`
+	// ConnectTimeout is the time to wait for a connection to a peer to be ready
+	ConnectTimeout time.Duration `yaml:"connectTimeout"`
+	// Backoff delays the next attempt to reach a failing peer
+	Backoff Backoff `yaml:"backoff"`
+	// Watch replaces polling the peers queried by the scan strategy with watches
+	// pushing their changes, for the peers which support it
+	Watch bool `yaml:"watch"`
+
+	Swim     Swim     `yaml:"swim"`
+	Kademlia Kademlia `yaml:"kademlia"`
+}
+
+// Eviction decides when unreachable peers are evicted from the peerstore.
+// Zero values are replaced by defaults
+type Eviction struct {
+	// MaxFailures evicts peers after this many consecutive failed contacts
+	MaxFailures int `yaml:"maxFailures"`
+	// MaxAge evicts peers not seen for this long
+	MaxAge time.Duration `yaml:"maxAge"`
+	// TombstoneTTL is the time an evicted peer is not re-added from other peers' lists
+	TombstoneTTL time.Duration `yaml:"tombstoneTTL"`
+}
+
+// Store selects and configures the peerstore implementation
+type Store struct {
+	// Type is either "memory" or "file". It defaults to "file" if Dir is set
+	// and to "memory" otherwise
+	Type string `yaml:"type"`
+	// Dir is the data directory persisting the peerstore across restarts
+	Dir string `yaml:"dir"`
+}
+
+// Cluster configures the membership of the local node in the cluster named by
+// Local.ClusterName
+type Cluster struct {
+	// Isolated only accepts peers of the local cluster and the gateways
+	Isolated bool `yaml:"isolated"`
+	// Gateways are the addresses of peers relaying discovery between clusters.
+	// They are accepted whatever their cluster is, and so are the peers of other
+	// clusters they serve
+	Gateways []string `yaml:"gateways"`
+}
+
+// Pubsub configures the topic meshes used to disseminate pubsub messages.
+// Zero values are replaced by defaults
+type Pubsub struct {
+	// Degree is the number of peers every topic mesh aims for
+	Degree int `yaml:"degree"`
+	// DegreeLow grafts peers into a mesh smaller than this
+	DegreeLow int `yaml:"degreeLow"`
+	// DegreeHigh prunes peers from a mesh larger than this
+	DegreeHigh int `yaml:"degreeHigh"`
+	// HeartbeatInterval is the period of the mesh maintenance
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval"`
+	// SeenTTL is the time message IDs are remembered to drop duplicates
+	SeenTTL time.Duration `yaml:"seenTTL"`
+}
+
+// Kademlia configures the DHT discovery strategy
+type Kademlia struct {
+	// K is the size of the buckets and the number of peers returned by lookups
+	K int `yaml:"k"`
+	// Alpha is the number of concurrent requests of a lookup
+	Alpha int `yaml:"alpha"`
+	// RefreshInterval is the period of the bucket refresh
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+	// QueryTimeout is the time to wait for the reply of a peer during a lookup
+	QueryTimeout time.Duration `yaml:"queryTimeout"`
+	// RecordTTL is the time the records put by the node are stored. Records received
+	// from peers are stored at most this long whatever their expiry
+	RecordTTL time.Duration `yaml:"recordTTL"`
+	// RepublishInterval is the period the stored records are replicated again to
+	// the closest peers of their key
+	RepublishInterval time.Duration `yaml:"republishInterval"`
+}
+
+// Exchange limits the responses of the peer exchange served to neighbors
+type Exchange struct {
+	// PageSize is the maximum number of peers per response
+	PageSize int `yaml:"pageSize"`
+	// MaxResponseSize is the maximum size of a response in bytes
+	MaxResponseSize int `yaml:"maxResponseSize"`
+}
+
+// Routing configures the relaying of messages to peers which are not directly reachable
+type Routing struct {
+	// MaxHops is the maximum number of hops of a relayed message
+	MaxHops int `yaml:"maxHops"`
+	// LinkTTL is the time the neighbors reported by a peer are used to route messages
+	LinkTTL time.Duration `yaml:"linkTTL"`
 }
 
 type Config struct {
-	Local     Peer   `yaml:"local"`
-	Bootstrap []Peer `yaml:"bootstrap"`
+	Local     Peer      `yaml:"local"`
+	Bootstrap []Peer    `yaml:"bootstrap"`
+	TLS       TLS       `yaml:"tls"`
+	Identity  Identity  `yaml:"identity"`
+	Discovery Discovery `yaml:"discovery"`
+	Eviction  Eviction  `yaml:"eviction"`
+	Store     Store     `yaml:"store"`
+	Cluster   Cluster   `yaml:"cluster"`
+	Pubsub    Pubsub    `yaml:"pubsub"`
+	Routing   Routing   `yaml:"routing"`
+	Exchange  Exchange  `yaml:"exchange"`
 }
 
+// FromFile reads and validates the config file at path. Unlike Loader, it applies
+// neither defaults nor environment variables
 func FromFile(path string) (*Config, error) {
-	// read connection profile yaml file
-	cpb, err := os.ReadFile(path)
-	if err != nil {
+	cp := &Config{}
+	if _, err := decodeFile(path, cp); err != nil {
 		return nil, err
 	}
-
-	// unmarshal yaml to connection profile struct
-	cp := &Config{}
-	if err := yaml.Unmarshal(cpb, cp); err != nil {
+	if err := cp.Validate(); err != nil {
 		return nil, err
 	}
 	return cp, nil
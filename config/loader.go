@@ -0,0 +1,489 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix prefixes the environment variables read by the loader
+const EnvPrefix = "P2P_"
+
+var (
+	ErrUnknownKey    = errors.New("config: unknown key")
+	ErrInvalidValue  = errors.New("config: invalid value")
+	ErrUnknownFormat = errors.New("config: unknown file format")
+)
+
+// Source is the layer a config value comes from
+type Source string
+
+const (
+	SourceDefault  Source = "default"
+	SourceFile     Source = "file"
+	SourceEnv      Source = "env"
+	SourceOverride Source = "override"
+)
+
+// Origin locates the layer and the exact place a config value was set at
+type Origin struct {
+	Source Source
+	// Location is the file path, the environment variable or the override key
+	Location string
+}
+
+func (o Origin) String() string {
+	if o.Location == "" {
+		return string(o.Source)
+	}
+	return fmt.Sprintf("%s(%s)", o.Source, o.Location)
+}
+
+// Provenance maps every key of the config set by a layer to the layer which
+// set its final value. Keys are the yaml paths of the fields, e.g. local.addr
+type Provenance map[string]Origin
+
+// String lists the keys and their origin sorted by key
+func (p Provenance) String() string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, p[k])
+	}
+	return b.String()
+}
+
+// Default returns the config with the default values of the optional settings
+func Default() *Config {
+	return &Config{
+		Discovery: Discovery{
+			Strategy:       "scan",
+			Interval:       1 * time.Second,
+			ConnectTimeout: 5 * time.Second,
+			Backoff: Backoff{
+				Initial:    1 * time.Second,
+				Max:        1 * time.Minute,
+				Multiplier: 2,
+			},
+			Swim: Swim{
+				ProbeInterval:    1 * time.Second,
+				ProbeTimeout:     500 * time.Millisecond,
+				IndirectProbes:   3,
+				SuspicionTimeout: 5 * time.Second,
+				RetransmitMult:   4,
+			},
+			Kademlia: Kademlia{
+				K:                 20,
+				Alpha:             3,
+				RefreshInterval:   1 * time.Minute,
+				QueryTimeout:      2 * time.Second,
+				RecordTTL:         24 * time.Hour,
+				RepublishInterval: 1 * time.Hour,
+			},
+		},
+		Eviction: Eviction{
+			MaxFailures:  5,
+			MaxAge:       5 * time.Minute,
+			TombstoneTTL: 1 * time.Minute,
+		},
+		Pubsub: Pubsub{
+			Degree:            6,
+			DegreeLow:         4,
+			DegreeHigh:        12,
+			HeartbeatInterval: 1 * time.Second,
+			SeenTTL:           2 * time.Minute,
+		},
+		Routing: Routing{
+			MaxHops: 8,
+			LinkTTL: 1 * time.Minute,
+		},
+		Exchange: Exchange{
+			PageSize:        256,
+			MaxResponseSize: 1 << 20,
+		},
+	}
+}
+
+// Loader builds a config from layers applied in order: defaults, the config file,
+// the environment variables and the explicit overrides. Every layer only replaces
+// the values it sets
+type Loader struct {
+	path      string
+	lookupEnv func(string) (string, bool)
+	overrides []override
+}
+
+type override struct {
+	key   string
+	value string
+}
+
+// NewLoader creates a loader reading the environment of the process
+func NewLoader() *Loader {
+	return &Loader{
+		lookupEnv: os.LookupEnv,
+	}
+}
+
+// WithFile sets the config file. Its format is selected by its extension:
+// .json, .toml and YAML otherwise
+func (l *Loader) WithFile(path string) *Loader {
+	l.path = path
+	return l
+}
+
+// WithEnv replaces the function looking up environment variables.
+// A nil lookup disables the environment layer
+func (l *Loader) WithEnv(lookup func(string) (string, bool)) *Loader {
+	l.lookupEnv = lookup
+	return l
+}
+
+// Set overrides the value of the key, e.g. Set("local.addr", "0.0.0.0:8000").
+// Values are parsed like environment variables
+func (l *Loader) Set(key, value string) *Loader {
+	l.overrides = append(l.overrides, override{key: key, value: value})
+	return l
+}
+
+// RegisterFlags registers the -config flag selecting the config file and the
+// repeatable -set key=value flag overriding a value
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.Func("config", "path of the config file (yaml, json or toml)", func(v string) error {
+		l.WithFile(v)
+		return nil
+	})
+	fs.Func("set", "override a config value as key=value, e.g. local.addr=0.0.0.0:8000", func(v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("%w: %q is not key=value", ErrInvalidValue, v)
+		}
+		l.Set(key, value)
+		return nil
+	})
+}
+
+// Load applies the layers, validates the resulting config and returns the config
+// with the origin of its values
+func (l *Loader) Load() (*Config, Provenance, error) {
+	cfg := Default()
+	prov := make(Provenance)
+
+	// the defaults are the keys with a non zero default value
+	zero := flatten(&Config{})
+	for k, v := range flatten(cfg) {
+		if zero[k] != v {
+			prov[k] = Origin{Source: SourceDefault}
+		}
+	}
+
+	if l.path != "" {
+		set, err := decodeFile(l.path, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, k := range set {
+			prov[k] = Origin{Source: SourceFile, Location: l.path}
+		}
+	}
+
+	if l.lookupEnv != nil {
+		for _, key := range keys(reflect.TypeOf(*cfg), "") {
+			name := EnvName(key)
+			value, ok := l.lookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setKey(cfg, key, value); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			prov[key] = Origin{Source: SourceEnv, Location: name}
+		}
+	}
+
+	for _, o := range l.overrides {
+		if err := setKey(cfg, o.key, o.value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", o.key, err)
+		}
+		prov[o.key] = Origin{Source: SourceOverride, Location: o.key}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, prov, nil
+}
+
+// EnvName returns the environment variable of a config key,
+// e.g. local.clusterName is read from P2P_LOCAL_CLUSTER_NAME
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, part := range strings.Split(key, ".") {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteString(snakeCase(part))
+	}
+	return b.String()
+}
+
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// decodeFile decodes the config file over cfg and returns the keys present in
+// the file. JSON and TOML documents are converted to YAML so that all formats
+// share the yaml keys and value syntax
+func decodeFile(path string, cfg *Config) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var doc interface{}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		if b, err = yaml.Marshal(doc); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var doc map[string]interface{}
+		if _, err := toml.Decode(string(b), &doc); err != nil {
+			return nil, err
+		}
+		if b, err = yaml.Marshal(doc); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml", "":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	var doc map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return presentKeys(doc), nil
+}
+
+// presentKeys returns the config keys set by a decoded yaml document
+func presentKeys(doc map[interface{}]interface{}) []string {
+	var present []string
+	for _, key := range keys(reflect.TypeOf(Config{}), "") {
+		node := doc
+		parts := strings.Split(key, ".")
+		for i, part := range parts {
+			v, ok := node[part]
+			if !ok {
+				break
+			}
+			if i == len(parts)-1 {
+				present = append(present, key)
+				break
+			}
+			if node, ok = v.(map[interface{}]interface{}); !ok {
+				break
+			}
+		}
+	}
+	return present
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	peersType    = reflect.TypeOf([]Peer(nil))
+)
+
+// keys returns the keys of the settable fields of t in declaration order
+func keys(t reflect.Type, prefix string) []string {
+	var list []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := prefix + yamlName(f)
+		if f.Type.Kind() == reflect.Struct {
+			list = append(list, keys(f.Type, key+".")...)
+			continue
+		}
+		list = append(list, key)
+	}
+	return list
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// field returns the field of cfg selected by key
+func field(cfg *Config, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if yamlName(v.Type().Field(i)) == part {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+		}
+	}
+	if v.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+	return v, nil
+}
+
+// setKey parses value into the field selected by key.
+// Lists are comma separated, maps are comma separated k=v pairs and bootstrap
+// peers are written as [[cluster/]name@]addr, e.g.
+// P2P_BOOTSTRAP=127.0.0.1:9001,peer1@127.0.0.1:9002,cluster_1/peer0@127.0.0.1:9100.
+// The cluster of a bootstrap peer defaults to the local cluster and its name
+// defaults to its address
+func setKey(cfg *Config, key, value string) error {
+	v, err := field(cfg, key)
+	if err != nil {
+		return err
+	}
+	invalid := fmt.Errorf("%w: %q", ErrInvalidValue, value)
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return invalid
+		}
+		v.SetInt(int64(d))
+	case v.Type() == peersType:
+		v.Set(reflect.ValueOf(parsePeers(value, cfg.Local.ClusterName)))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return invalid
+		}
+		v.SetInt(int64(i))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(value)))
+	case v.Kind() == reflect.Map:
+		m := make(map[string]string)
+		for _, kv := range splitList(value) {
+			k, val, ok := strings.Cut(kv, "=")
+			if !ok {
+				return invalid
+			}
+			m[k] = val
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// parsePeers parses a list of [[cluster/]name@]addr peers
+func parsePeers(value, cluster string) []Peer {
+	var peers []Peer
+	for _, s := range splitList(value) {
+		p := Peer{Addr: s, Name: s, ClusterName: cluster}
+		if id, addr, ok := strings.Cut(s, "@"); ok {
+			p.Addr = addr
+			p.Name = id
+			if c, name, ok := strings.Cut(id, "/"); ok {
+				p.ClusterName, p.Name = c, name
+			}
+		}
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// flatten returns the printed value of every key of the config
+func flatten(cfg *Config) map[string]string {
+	values := make(map[string]string)
+	for _, key := range keys(reflect.TypeOf(*cfg), "") {
+		v, _ := field(cfg, key)
+		values[key] = fmt.Sprintf("%v", v.Interface())
+	}
+	return values
+}
+
+// Diff returns the sorted keys whose values differ between a and b
+func Diff(a, b *Config) []string {
+	av, bv := flatten(a), flatten(b)
+	var changed []string
+	for k, v := range av {
+		if bv[k] != v {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func envOf(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	path := writeFile(t, "node.yaml", `
+local:
+  addr: 127.0.0.1:9001
+  name: node
+  clusterName: cluster
+discovery:
+  interval: 3s
+eviction:
+  maxFailures: 7
+`)
+	env := map[string]string{
+		"P2P_LOCAL_ADDR":            "127.0.0.1:9002",
+		"P2P_EVICTION_MAX_FAILURES": "9",
+	}
+
+	cfg, prov, err := NewLoader().WithFile(path).WithEnv(envOf(env)).Set("eviction.maxFailures", "11").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		got    interface{}
+		want   interface{}
+		origin Origin
+	}{
+		{"local.addr", cfg.Local.Addr, "127.0.0.1:9002", Origin{Source: SourceEnv, Location: "P2P_LOCAL_ADDR"}},
+		{"local.name", cfg.Local.Name, "node", Origin{Source: SourceFile, Location: path}},
+		{"discovery.interval", cfg.Discovery.Interval, 3 * time.Second, Origin{Source: SourceFile, Location: path}},
+		{"discovery.connectTimeout", cfg.Discovery.ConnectTimeout, 5 * time.Second, Origin{Source: SourceDefault}},
+		{"eviction.maxFailures", cfg.Eviction.MaxFailures, 11, Origin{Source: SourceOverride, Location: "eviction.maxFailures"}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+		if prov[tt.key] != tt.origin {
+			t.Errorf("%s comes from %s, want %s", tt.key, prov[tt.key], tt.origin)
+		}
+	}
+}
+
+func TestLoaderProvenanceOfDefaultValueInFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"node.yaml": "local: {addr: 127.0.0.1:9001, name: node, clusterName: cluster}\ndiscovery: {strategy: scan}\n",
+		"node.json": `{"local": {"addr": "127.0.0.1:9001", "name": "node", "clusterName": "cluster"}, "discovery": {"strategy": "scan"}}`,
+		"node.toml": "[local]\naddr = \"127.0.0.1:9001\"\nname = \"node\"\nclusterName = \"cluster\"\n[discovery]\nstrategy = \"scan\"\n",
+	} {
+		path := writeFile(t, name, content)
+		_, prov, err := NewLoader().WithFile(path).WithEnv(nil).Load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := (Origin{Source: SourceFile, Location: path}); prov["discovery.strategy"] != want {
+			t.Errorf("%s: discovery.strategy comes from %s, want %s", name, prov["discovery.strategy"], want)
+		}
+		if want := (Origin{Source: SourceDefault}); prov["discovery.interval"] != want {
+			t.Errorf("%s: discovery.interval comes from %s, want %s", name, prov["discovery.interval"], want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mr-shifu/grpc-p2p/address"
+)
+
+var (
+	ErrEmptyValue     = errors.New("config: value must not be empty")
+	ErrInvalidAddress = errors.New("config: invalid address")
+	ErrMissingPort    = errors.New("config: address must have a port")
+	ErrDuplicateValue = errors.New("config: duplicate value")
+	ErrIncompleteTLS  = errors.New("config: certFile and keyFile must be set together")
+)
+
+// FieldError is the error of a single config field
+type FieldError struct {
+	// Field is the path of the field, e.g. bootstrap[1].addr
+	Field string
+	// Value is the invalid value
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError contains the errors of all the invalid fields of a config
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("config: %d invalid field(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the field errors so that errors.Is and errors.As match any of them
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e *ValidationError) add(field, value string, err error) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Value: value, Err: err})
+}
+
+// Validate checks the whole config and returns a *ValidationError listing all the
+// invalid fields. Addresses are checked with the rules used by the peerstore
+func (c *Config) Validate() error {
+	v := &ValidationError{}
+
+	validatePeer(v, "local", c.Local)
+	// the local address is listened on, so a bare IP is not enough
+	if addr, err := address.Normalize(c.Local.Addr); err == nil {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			v.add("local.addr", c.Local.Addr, ErrMissingPort)
+		}
+	}
+
+	addrs := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, p := range c.Bootstrap {
+		field := fmt.Sprintf("bootstrap[%d]", i)
+		validatePeer(v, field, p)
+
+		if addr, err := address.Normalize(p.Addr); err == nil {
+			if addrs[addr] {
+				v.add(field+".addr", p.Addr, ErrDuplicateValue)
+			}
+			addrs[addr] = true
+		}
+		if p.Name != "" {
+			name := p.ClusterName + "/" + p.Name
+			if names[name] {
+				v.add(field+".name", p.Name, ErrDuplicateValue)
+			}
+			names[name] = true
+		}
+	}
+
+	for i, addr := range c.Cluster.Gateways {
+		if !address.Valid(addr) {
+			v.add(fmt.Sprintf("cluster.gateways[%d]", i), addr, ErrInvalidAddress)
+		}
+	}
+
+	if c.TLS.CertFile == "" && c.TLS.KeyFile != "" {
+		v.add("tls.certFile", c.TLS.CertFile, ErrIncompleteTLS)
+	}
+	if c.TLS.CertFile != "" && c.TLS.KeyFile == "" {
+		v.add("tls.keyFile", c.TLS.KeyFile, ErrIncompleteTLS)
+	}
+
+	if len(v.Errors) > 0 {
+		return v
+	}
+	return nil
+}
+
+func validatePeer(v *ValidationError, field string, p Peer) {
+	if !address.Valid(p.Addr) {
+		v.add(field+".addr", p.Addr, ErrInvalidAddress)
+	}
+	if p.Name == "" {
+		v.add(field+".name", p.Name, ErrEmptyValue)
+	}
+	if p.ClusterName == "" {
+		v.add(field+".clusterName", p.ClusterName, ErrEmptyValue)
+	}
+}
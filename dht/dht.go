@@ -0,0 +1,269 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultK               = 20
+	defaultAlpha           = 3
+	defaultRefreshInterval = 1 * time.Minute
+	defaultQueryTimeout    = 2 * time.Second
+	defaultRecordTTL       = 24 * time.Hour
+	defaultRepublish       = 1 * time.Hour
+
+	// bootstrapInterval is the period of the rounds while the routing table is empty
+	bootstrapInterval = 1 * time.Second
+)
+
+var (
+	ErrPeerNotFound  = errors.New("dht: peer not found")
+	ErrInvalidRecord = errors.New("dht: invalid peer record")
+)
+
+// DHT is a discovery strategy implementing the Kademlia DHT. Peers are placed in a
+// 256 bits key space at their ID and every node keeps the peers of k-buckets of
+// decreasing distance to its own ID instead of every peer of the mesh. Peers are
+// located with iterative lookups querying the closest known peers for closer ones.
+// The peerstore holds the contacts of the routing table
+type DHT struct {
+	cfg   config.Kademlia
+	ps    *peer.PeerService
+	clock clock.Clock
+	self  Key
+	table *table
+
+	lock sync.Mutex
+	// records are the records stored by the local node as one of the closest peers of their key
+	records map[Key]*Record
+	// published are the records put by the local node which it republishes until stopped
+	published  map[Key]*Record
+	validators map[string]Validator
+	publishers map[string]PublisherValidator
+
+	logger zerolog.Logger
+}
+
+// New creates a new Kademlia DHT. Zero config values are replaced by defaults
+func New(cfg config.Kademlia, ps *peer.PeerService, logger zerolog.Logger) (*DHT, error) {
+	if cfg.K <= 0 {
+		cfg.K = defaultK
+	}
+	if cfg.Alpha <= 0 {
+		cfg.Alpha = defaultAlpha
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+	if cfg.QueryTimeout <= 0 {
+		cfg.QueryTimeout = defaultQueryTimeout
+	}
+	if cfg.RecordTTL <= 0 {
+		cfg.RecordTTL = defaultRecordTTL
+	}
+	if cfg.RepublishInterval <= 0 {
+		cfg.RepublishInterval = defaultRepublish
+	}
+
+	self, err := KeyFromID(ps.Identity().ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &DHT{
+		cfg:        cfg,
+		ps:         ps,
+		clock:      ps.Clock(),
+		self:       self,
+		table:      newTable(self, cfg.K, ps.Clock().Now()),
+		records:    make(map[Key]*Record),
+		published:  make(map[Key]*Record),
+		validators: make(map[string]Validator),
+		publishers: make(map[string]PublisherValidator),
+		logger:     logger,
+	}, nil
+}
+
+// RegisterService registers the kademlia rpc service to the server
+func (d *DHT) RegisterService(srv grpc.ServiceRegistrar) {
+	p2p_pb.RegisterKademliaServer(srv, &server{d: d})
+}
+
+// Start joins the DHT through the peers of the peerstore and refreshes the buckets
+// every refresh interval until ctx is done. Rounds run every second while the
+// routing table is empty. The stored records are replicated every republish interval
+func (d *DHT) Start(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	republish := time.NewTicker(d.cfg.RepublishInterval)
+	defer republish.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-republish.C:
+			d.republish(ctx)
+			continue
+		case <-timer.C:
+		}
+
+		if len(d.table.contacts()) == 0 {
+			d.join(ctx)
+		} else {
+			d.refresh(ctx)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		d.evict()
+		d.expire()
+
+		if len(d.table.contacts()) == 0 {
+			timer.Reset(bootstrapInterval)
+		} else {
+			timer.Reset(d.cfg.RefreshInterval)
+		}
+	}
+}
+
+// join looks up the local key through the peers of the peerstore, e.g. the bootstrap
+// peers, which fills the buckets with the peers closest to the local node
+func (d *DHT) join(ctx context.Context) {
+	var seeds []*peer.Peer
+	for _, p := range d.ps.GetPeers() {
+		if p.Addr() != d.ps.Self().Addr() {
+			seeds = append(seeds, p)
+		}
+	}
+	if len(seeds) == 0 {
+		return
+	}
+
+	for _, p := range seeds {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := d.findNode(ctx, d.ps.Client(p.Addr()), d.self); err != nil {
+			d.ps.MarkFailed(p.Addr())
+		}
+	}
+	d.Lookup(ctx, d.self)
+}
+
+// refresh looks up a random key in every bucket which was not looked up since the
+// refresh interval
+func (d *DHT) refresh(ctx context.Context) {
+	for _, i := range d.table.stale(d.clock.Now(), d.cfg.RefreshInterval) {
+		if ctx.Err() != nil {
+			return
+		}
+		d.Lookup(ctx, randomKey(d.self, i))
+	}
+}
+
+// evict evicts the unreachable peers from the peerstore and removes the contacts
+// which are not in the peerstore anymore from the routing table
+func (d *DHT) evict() {
+	for _, p := range d.ps.Evict() {
+		d.logger.Info().Str("peer", p.Addr()).Msg("evicted peer")
+	}
+	for _, c := range d.table.contacts() {
+		if _, err := d.ps.GetPeerByID(c.record.ID); err != nil {
+			d.table.remove(c.key)
+		}
+	}
+}
+
+// FindPeer returns the peer with the given ID, looking it up in the DHT if it is
+// not in the routing table. It returns ErrPeerNotFound if no peer has the ID
+func (d *DHT) FindPeer(ctx context.Context, id string) (*peer.Peer, error) {
+	key, err := KeyFromID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p, err := d.ps.GetPeerByID(id); err == nil {
+		return p, nil
+	}
+
+	for _, r := range d.Lookup(ctx, key) {
+		if r.ID == id {
+			return peer.NewPeerFromRecord(r), nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrPeerNotFound
+}
+
+// Closest returns the records of up to K contacts of the routing table closest to key
+func (d *DHT) Closest(key Key) []*peer.Record {
+	var records []*peer.Record
+	for _, c := range d.table.closest(key, d.cfg.K) {
+		records = append(records, c.record)
+	}
+	return records
+}
+
+// caller returns the verified record of the caller if it is admitted by the cluster membership
+func (d *DHT) caller(ctx context.Context, pb *p2p_pb.PeerRecord) (*peer.Record, error) {
+	r, err := d.ps.AdmitCaller(ctx, pb)
+	if errors.Is(err, peer.ErrPeerNotAdmitted) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrInvalidRecord
+	}
+	return r, nil
+}
+
+// closer returns the records of the contacts closest to key excluding the caller
+func (d *DHT) closer(key Key, caller *peer.Record) []*p2p_pb.PeerRecord {
+	var records []*p2p_pb.PeerRecord
+	for _, r := range d.Closest(key) {
+		if r.ID != caller.ID {
+			records = append(records, peer.RecordToPb(r))
+		}
+	}
+	return records
+}
+
+// observe adds the peer of a verified record to the routing table and the peerstore.
+// A contact replaced in its full bucket is removed from the peerstore
+func (d *DHT) observe(r *peer.Record) bool {
+	key, err := KeyFromID(r.ID)
+	if err != nil || key == d.self || !d.ps.Admits(peer.NewPeerFromRecord(r)) {
+		return false
+	}
+
+	added, replaced := d.table.update(&contact{key: key, record: r}, d.alive)
+	if !added {
+		return false
+	}
+	// a stale record means the peer is already stored with the same or a newer record
+	if _, err := d.ps.PutRecord(r); err != nil && !errors.Is(err, peer.ErrStaleRecord) {
+		d.table.remove(key)
+		return false
+	}
+	if replaced != nil {
+		d.ps.RemovePeer(replaced.addr())
+	}
+	return true
+}
+
+// alive returns true if the connection to the peer is ready
+func (d *DHT) alive(addr string) bool {
+	state, err := d.ps.GetState(addr)
+	return err == nil && state == peer.Ready
+}
@@ -0,0 +1,99 @@
+package dht
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+	"math/rand"
+)
+
+// KeyBits is the size of the keys in bits
+const KeyBits = 256
+
+var ErrInvalidKey = errors.New("dht: invalid key")
+
+// Key is a point of the DHT key space. Peers are placed at the key of their ID and
+// values at the SHA-256 digest of their key
+type Key [KeyBits / 8]byte
+
+// KeyFromID returns the key of a peer ID, which is the hex encoded SHA-256 digest
+// of the peer public key
+func KeyFromID(id string) (Key, error) {
+	var k Key
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != len(k) {
+		return k, ErrInvalidKey
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// KeyFromBytes returns the key of its raw representation
+func KeyFromBytes(b []byte) (Key, error) {
+	var k Key
+	if len(b) != len(k) {
+		return k, ErrInvalidKey
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// HashKey returns the key a value stored under key is placed at
+func HashKey(key string) Key {
+	return Key(sha256.Sum256([]byte(key)))
+}
+
+// Distance returns the XOR distance between the keys
+func (k Key) Distance(o Key) Key {
+	var d Key
+	for i := range k {
+		d[i] = k[i] ^ o[i]
+	}
+	return d
+}
+
+// Less returns true if k is smaller than o
+func (k Key) Less(o Key) bool {
+	return bytes.Compare(k[:], o[:]) < 0
+}
+
+// String returns the hex encoding of the key
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// commonPrefixLen returns the number of leading bits shared by the keys
+func commonPrefixLen(a, b Key) int {
+	for i := range a {
+		if x := a[i] ^ b[i]; x != 0 {
+			return i*8 + bits.LeadingZeros8(x)
+		}
+	}
+	return KeyBits
+}
+
+// randomKey returns a random key sharing exactly prefix leading bits with k
+func randomKey(k Key, prefix int) Key {
+	var r Key
+	rand.Read(r[:])
+	for i := 0; i < prefix; i++ {
+		setBit(&r, i, bit(k, i))
+	}
+	setBit(&r, prefix, 1-bit(k, prefix))
+	return r
+}
+
+func bit(k Key, i int) byte {
+	return (k[i/8] >> (7 - i%8)) & 1
+}
+
+func setBit(k *Key, i int, v byte) {
+	mask := byte(1) << (7 - i%8)
+	if v == 1 {
+		k[i/8] |= mask
+	} else {
+		k[i/8] &^= mask
+	}
+}
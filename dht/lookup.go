@@ -0,0 +1,231 @@
+package dht
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc"
+)
+
+// lookup is the state of an iterative lookup: the closest peers found so far and
+// the peers already queried
+type lookup struct {
+	key     Key
+	k       int
+	closest []*contact
+	seen    map[Key]bool
+	queried map[Key]bool
+}
+
+func newLookup(key Key, k int) *lookup {
+	return &lookup{
+		key:     key,
+		k:       k,
+		seen:    make(map[Key]bool),
+		queried: make(map[Key]bool),
+	}
+}
+
+// add adds the peers of the records to the candidates and keeps the K closest
+func (l *lookup) add(records []*peer.Record) {
+	for _, r := range records {
+		key, err := KeyFromID(r.ID)
+		if err != nil || l.seen[key] {
+			continue
+		}
+		l.seen[key] = true
+		l.closest = append(l.closest, &contact{key: key, record: r})
+	}
+	sortByDistance(l.closest, l.key)
+	if len(l.closest) > l.k {
+		l.closest = l.closest[:l.k]
+	}
+}
+
+// next returns up to n of the K closest peers which were not queried yet
+func (l *lookup) next(n int) []*contact {
+	var next []*contact
+	for _, c := range l.closest {
+		if len(next) == n {
+			break
+		}
+		if !l.queried[c.key] {
+			l.queried[c.key] = true
+			next = append(next, c)
+		}
+	}
+	return next
+}
+
+// results returns the records of the closest peers which answered
+func (l *lookup) results(failed map[Key]bool) []*peer.Record {
+	var records []*peer.Record
+	for _, c := range l.closest {
+		if !failed[c.key] {
+			records = append(records, c.record)
+		}
+	}
+	return records
+}
+
+// queryFunc queries the peer on conn for the peers closer to the key of a lookup
+// and the record stored under the key if any
+type queryFunc func(ctx context.Context, conn grpc.ClientConnInterface) ([]*peer.Record, *Record, error)
+
+// Lookup returns the records of up to K peers closest to key. It queries the closest
+// peers known, Alpha at a time, for closer peers until the K closest peers found
+// have all been queried
+func (d *DHT) Lookup(ctx context.Context, key Key) []*peer.Record {
+	closest, _ := d.lookup(ctx, key, func(ctx context.Context, conn grpc.ClientConnInterface) ([]*peer.Record, *Record, error) {
+		closer, err := d.findNode(ctx, conn, key)
+		return closer, nil, err
+	})
+	return closest
+}
+
+// lookup runs an iterative lookup of key with query. It stops early once a peer
+// returned a record and returns the record with the highest Seq of all rounds
+func (d *DHT) lookup(ctx context.Context, key Key, query queryFunc) ([]*peer.Record, *Record) {
+	d.table.touch(key, d.clock.Now())
+
+	l := newLookup(key, d.cfg.K)
+	l.add(d.Closest(key))
+	failed := make(map[Key]bool)
+	var best *Record
+
+	for ctx.Err() == nil {
+		next := l.next(d.cfg.Alpha)
+		if len(next) == 0 {
+			break
+		}
+
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		var found []*peer.Record
+		for _, c := range next {
+			wg.Add(1)
+			go func(c *contact) {
+				defer wg.Done()
+				var records []*peer.Record
+				var record *Record
+				err := d.withPeer(c.record, func(conn grpc.ClientConnInterface) (err error) {
+					records, record, err = query(ctx, conn)
+					return err
+				})
+
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					failed[c.key] = true
+					d.ps.MarkFailed(c.record.Addrs[0])
+					return
+				}
+				found = append(found, records...)
+				if record != nil && (best == nil || record.Seq > best.Seq) {
+					best = record
+				}
+			}(c)
+		}
+		wg.Wait()
+
+		if best != nil {
+			break
+		}
+		l.add(found)
+	}
+	return l.results(failed), best
+}
+
+// withPeer calls fn with a connection to the peer of r. The pooled connection of the
+// peer service is used for the peers of the peerstore, while other lookup candidates
+// are dialed for the time of the call so that the peerstore only stores the peers
+// entering the routing table
+func (d *DHT) withPeer(r *peer.Record, fn func(conn grpc.ClientConnInterface) error) error {
+	if _, err := d.ps.GetPeerByID(r.ID); err == nil {
+		return fn(d.ps.Client(r.Addrs[0]))
+	}
+
+	conn, err := d.ps.Dial(r.Addrs[0])
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// findNode sends a FindNode request to the peer on conn and returns the verified
+// records of the closer peers. The peer is added to the routing table if it answered
+func (d *DHT) findNode(ctx context.Context, conn grpc.ClientConnInterface, key Key) ([]*peer.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.QueryTimeout)
+	defer cancel()
+
+	res, err := p2p_pb.NewKademliaClient(conn).FindNode(ctx, &p2p_pb.FindNodeRequest{
+		Self: peer.RecordToPb(d.ps.Self().Record),
+		Key:  key[:],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r := d.verify(res.Self); r != nil {
+		d.observe(r)
+	}
+	return d.verifyAll(res.Closer), nil
+}
+
+// findValue sends a FindValue request to the peer on conn and returns the verified
+// records of the closer peers or the valid record stored under key
+func (d *DHT) findValue(ctx context.Context, conn grpc.ClientConnInterface, key Key) ([]*peer.Record, *Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.QueryTimeout)
+	defer cancel()
+
+	res, err := p2p_pb.NewKademliaClient(conn).FindValue(ctx, &p2p_pb.FindValueRequest{
+		Self: peer.RecordToPb(d.ps.Self().Record),
+		Key:  key[:],
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r := d.verify(res.Self); r != nil {
+		d.observe(r)
+	}
+	// an invalid record is ignored as if the peer did not store any
+	if r := recordFromPb(res.Record); r != nil && HashKey(r.Key) == key && d.validate(r) == nil {
+		return nil, r, nil
+	}
+	return d.verifyAll(res.Closer), nil, nil
+}
+
+// put sends the record to the peer on conn
+func (d *DHT) put(ctx context.Context, conn grpc.ClientConnInterface, r *Record) error {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.QueryTimeout)
+	defer cancel()
+
+	_, err := p2p_pb.NewKademliaClient(conn).Put(ctx, &p2p_pb.PutRequest{
+		Self:   peer.RecordToPb(d.ps.Self().Record),
+		Record: recordToPb(r),
+	})
+	return err
+}
+
+// verify returns the record if its signature is valid and it is not the record of self
+func (d *DHT) verify(pb *p2p_pb.PeerRecord) *peer.Record {
+	r := peer.RecordFromPb(pb)
+	if r == nil || r.Verify() != nil || r.ID == d.ps.Identity().ID() {
+		return nil
+	}
+	return r
+}
+
+func (d *DHT) verifyAll(pbs []*p2p_pb.PeerRecord) []*peer.Record {
+	var records []*peer.Record
+	for _, pb := range pbs {
+		if r := d.verify(pb); r != nil {
+			records = append(records, r)
+		}
+	}
+	return records
+}
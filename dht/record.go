@@ -0,0 +1,136 @@
+package dht
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/identity"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+)
+
+var (
+	ErrInvalidRecordKey       = errors.New("dht: record key must be of the form /namespace/name")
+	ErrInvalidRecordSignature = errors.New("dht: invalid record signature")
+	ErrRecordExpired          = errors.New("dht: record expired")
+	ErrRecordNotFound         = errors.New("dht: record not found")
+	ErrStaleRecord            = errors.New("dht: record is not newer than the stored one")
+	ErrPublisherMismatch      = errors.New("dht: record key is owned by another publisher")
+)
+
+// recordDomain separates DHT record signatures from any other use of the node key
+const recordDomain = "grpc-p2p/dht-record"
+
+// Record is a value stored in the DHT under a key of the form /namespace/name.
+// Records are signed by their publisher and replicated to the K peers closest to
+// the SHA-256 digest of their key until they expire
+type Record struct {
+	Key   string
+	Value []byte
+	// Seq orders the records of a key. The record with the highest Seq wins
+	Seq     uint64
+	Expires time.Time
+	// Publisher is the ID of the peer which published the record
+	Publisher string
+	PublicKey ed25519.PublicKey
+	Signature []byte
+
+	// deadline is the local expiry of a stored record received from a peer. It is
+	// not signed and bounds the expiry chosen by the publisher
+	deadline time.Time
+}
+
+// Validator checks the values put under the keys of a namespace. A record whose
+// value is rejected is neither stored nor returned by Get
+type Validator func(key string, value []byte) error
+
+// PublisherValidator checks whether a record may replace the unexpired record of
+// another publisher stored under its key. Without a publisher validator only the
+// publisher of the stored record can replace it
+type PublisherValidator func(stored, record *Record) error
+
+// Namespace returns the namespace of a record key, e.g. "endpoints" for "/endpoints/api"
+func Namespace(key string) (string, error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 || parts[0] != "" || parts[1] == "" || parts[2] == "" {
+		return "", ErrInvalidRecordKey
+	}
+	return parts[1], nil
+}
+
+// sign sets the publisher of the record to the identity and signs the record
+func (r *Record) sign(id *identity.Identity) {
+	r.Publisher = id.ID()
+	r.PublicKey = id.PublicKey()
+	r.Signature = id.Sign(r.payload())
+}
+
+// verify checks that the publisher ID is derived from the public key and the signature is valid
+func (r *Record) verify() error {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return ErrInvalidRecordSignature
+	}
+	if identity.IDFromPublicKey(r.PublicKey) != r.Publisher {
+		return ErrInvalidRecordSignature
+	}
+	if !ed25519.Verify(r.PublicKey, r.payload(), r.Signature) {
+		return ErrInvalidRecordSignature
+	}
+	return nil
+}
+
+// expired returns true if the record expired at now
+func (r *Record) expired(now time.Time) bool {
+	if !r.deadline.IsZero() && !now.Before(r.deadline) {
+		return true
+	}
+	return !now.Before(r.Expires)
+}
+
+// payload returns the deterministic encoding of the record signed by the publisher
+func (r *Record) payload() []byte {
+	var b bytes.Buffer
+	writeField(&b, []byte(recordDomain))
+	writeField(&b, []byte(r.Key))
+	writeField(&b, r.Value)
+	binary.Write(&b, binary.BigEndian, r.Seq)
+	binary.Write(&b, binary.BigEndian, r.Expires.UnixNano())
+	writeField(&b, []byte(r.Publisher))
+	writeField(&b, r.PublicKey)
+	return b.Bytes()
+}
+
+func writeField(b *bytes.Buffer, v []byte) {
+	binary.Write(b, binary.BigEndian, uint32(len(v)))
+	b.Write(v)
+}
+
+func recordToPb(r *Record) *p2p_pb.DHTRecord {
+	return &p2p_pb.DHTRecord{
+		Key:       r.Key,
+		Value:     r.Value,
+		Seq:       r.Seq,
+		Expires:   r.Expires.UnixNano(),
+		Publisher: r.Publisher,
+		PublicKey: r.PublicKey,
+		Signature: r.Signature,
+	}
+}
+
+func recordFromPb(pb *p2p_pb.DHTRecord) *Record {
+	if pb == nil {
+		return nil
+	}
+	return &Record{
+		Key:       pb.Key,
+		Value:     pb.Value,
+		Seq:       pb.Seq,
+		Expires:   time.Unix(0, pb.Expires),
+		Publisher: pb.Publisher,
+		PublicKey: pb.PublicKey,
+		Signature: pb.Signature,
+	}
+}
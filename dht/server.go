@@ -0,0 +1,79 @@
+package dht
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+)
+
+// server implements the kademlia rpc service on behalf of the DHT
+type server struct {
+	d *DHT
+
+	p2p_pb.UnimplementedKademliaServer
+}
+
+// FindNode returns the contacts closest to the requested key and adds the caller to
+// the routing table
+func (s *server) FindNode(ctx context.Context, req *p2p_pb.FindNodeRequest) (*p2p_pb.FindNodeResponse, error) {
+	key, err := KeyFromBytes(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := s.d.caller(ctx, req.Self)
+	if err != nil {
+		return nil, err
+	}
+	s.d.observe(caller)
+
+	return &p2p_pb.FindNodeResponse{
+		Self:   peer.RecordToPb(s.d.ps.Self().Record),
+		Closer: s.d.closer(key, caller),
+	}, nil
+}
+
+// FindValue returns the record stored under the requested key if any and the
+// contacts closest to the key otherwise
+func (s *server) FindValue(ctx context.Context, req *p2p_pb.FindValueRequest) (*p2p_pb.FindValueResponse, error) {
+	key, err := KeyFromBytes(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := s.d.caller(ctx, req.Self)
+	if err != nil {
+		return nil, err
+	}
+	s.d.observe(caller)
+
+	res := &p2p_pb.FindValueResponse{Self: peer.RecordToPb(s.d.ps.Self().Record)}
+	if r := s.d.record(key); r != nil {
+		res.Record = recordToPb(r)
+	} else {
+		res.Closer = s.d.closer(key, caller)
+	}
+	return res, nil
+}
+
+// Put stores the record sent by a peer if it is valid. A record older than the stored
+// one is not an error as the peer holds a newer record already
+func (s *server) Put(ctx context.Context, req *p2p_pb.PutRequest) (*p2p_pb.PutResponse, error) {
+	caller, err := s.d.caller(ctx, req.Self)
+	if err != nil {
+		return nil, err
+	}
+	s.d.observe(caller)
+
+	r := recordFromPb(req.Record)
+	if r == nil {
+		return nil, ErrRecordNotFound
+	}
+	if err := s.d.validate(r); err != nil {
+		return nil, err
+	}
+	if err := s.d.store(r); err != nil && !errors.Is(err, ErrStaleRecord) {
+		return nil, err
+	}
+	return &p2p_pb.PutResponse{}, nil
+}
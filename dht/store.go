@@ -0,0 +1,258 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	"google.golang.org/grpc"
+)
+
+var ErrNoReplicas = errors.New("dht: record not stored by any peer")
+
+// RegisterValidator registers the validator of the values put under the keys of namespace
+func (d *DHT) RegisterValidator(namespace string, v Validator) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.validators[namespace] = v
+}
+
+// RegisterPublisherValidator registers the validator of the records replacing the
+// record of another publisher under the keys of namespace
+func (d *DHT) RegisterPublisherValidator(namespace string, v PublisherValidator) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.publishers[namespace] = v
+}
+
+// Put publishes value under key, which must be of the form /namespace/name, on the
+// K peers closest to the key. The record expires after the record TTL unless the
+// local node republishes it. It returns ErrNoReplicas if no peer stored the record
+func (d *DHT) Put(ctx context.Context, key string, value []byte) error {
+	now := d.clock.Now()
+	r := &Record{
+		Key:     key,
+		Value:   value,
+		Seq:     uint64(now.UnixNano()),
+		Expires: now.Add(d.cfg.RecordTTL),
+	}
+	r.sign(d.ps.Identity())
+	if err := d.validate(r); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	d.published[HashKey(key)] = r
+	d.lock.Unlock()
+
+	return d.replicate(ctx, r)
+}
+
+// Get looks up key in the DHT and returns the record with the highest Seq found,
+// including the record stored by the local node if any
+func (d *DHT) Get(ctx context.Context, key string) (*Record, error) {
+	if _, err := Namespace(key); err != nil {
+		return nil, err
+	}
+
+	hash := HashKey(key)
+	_, r := d.lookup(ctx, hash, func(ctx context.Context, conn grpc.ClientConnInterface) ([]*peer.Record, *Record, error) {
+		return d.findValue(ctx, conn, hash)
+	})
+	if local := d.record(hash); local != nil && (r == nil || local.Seq >= r.Seq) {
+		r = local
+	}
+	if r == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRecordNotFound
+	}
+	return r, nil
+}
+
+// replicate stores the record on the K peers closest to its key, including the local
+// node if it is one of them
+func (d *DHT) replicate(ctx context.Context, r *Record) error {
+	key := HashKey(r.Key)
+	closest := d.Lookup(ctx, key)
+
+	stored := 0
+	if d.closest(key, closest) && d.store(r) == nil {
+		stored++
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var lastErr error
+	for _, p := range closest {
+		wg.Add(1)
+		go func(p *peer.Record) {
+			defer wg.Done()
+			err := d.withPeer(p, func(conn grpc.ClientConnInterface) error {
+				return d.put(ctx, conn, r)
+			})
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				lastErr = err
+				return
+			}
+			stored++
+		}(p)
+	}
+	wg.Wait()
+
+	if stored == 0 {
+		if lastErr != nil {
+			return fmt.Errorf("%w: %v", ErrNoReplicas, lastErr)
+		}
+		return ErrNoReplicas
+	}
+	return nil
+}
+
+// closest returns true if the local node is one of the K closest peers of key given
+// the closest peers found by a lookup
+func (d *DHT) closest(key Key, found []*peer.Record) bool {
+	if len(found) < d.cfg.K {
+		return true
+	}
+	farthest, err := KeyFromID(found[len(found)-1].ID)
+	if err != nil {
+		return true
+	}
+	return d.self.Distance(key).Less(farthest.Distance(key))
+}
+
+// republish renews and replicates the records published by the local node and
+// replicates the other stored records to the current closest peers of their key
+func (d *DHT) republish(ctx context.Context) {
+	now := d.clock.Now()
+
+	d.lock.Lock()
+	var records []*Record
+	for key, r := range d.published {
+		renewed := *r
+		renewed.Expires = now.Add(d.cfg.RecordTTL)
+		renewed.sign(d.ps.Identity())
+		d.published[key] = &renewed
+		records = append(records, &renewed)
+	}
+	for key, r := range d.records {
+		if _, ok := d.published[key]; !ok && !r.expired(now) {
+			records = append(records, r)
+		}
+	}
+	d.lock.Unlock()
+
+	for _, r := range records {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.replicate(ctx, r); err != nil {
+			d.logger.Debug().Err(err).Str("key", r.Key).Msg("failed to republish record")
+		}
+	}
+}
+
+// validate checks the key, the signature, the expiry and the value of the record
+// with the validator of its namespace
+func (d *DHT) validate(r *Record) error {
+	ns, err := Namespace(r.Key)
+	if err != nil {
+		return err
+	}
+	if err := r.verify(); err != nil {
+		return err
+	}
+	if r.expired(d.clock.Now()) {
+		return ErrRecordExpired
+	}
+
+	d.lock.Lock()
+	v := d.validators[ns]
+	d.lock.Unlock()
+
+	if v != nil {
+		return v(r.Key, r.Value)
+	}
+	return nil
+}
+
+// store stores a copy of the record unless a newer record of its key is stored. A record
+// with the same Seq replaces the stored one if it expires later. The record of another
+// publisher is only replaced if the publisher validator of the namespace allows it.
+// The stored record expires at the latest the record TTL after it was stored
+func (d *DHT) store(r *Record) error {
+	key := HashKey(r.Key)
+	ns, err := Namespace(r.Key)
+	if err != nil {
+		return err
+	}
+
+	for {
+		now := d.clock.Now()
+		d.lock.Lock()
+		cur := d.records[key]
+		v := d.publishers[ns]
+		d.lock.Unlock()
+
+		if cur != nil && !cur.expired(now) {
+			// the validator is called without the lock and the record is stored
+			// only if the stored record did not change meanwhile
+			if cur.Publisher != r.Publisher && (v == nil || v(cur, r) != nil) {
+				return ErrPublisherMismatch
+			}
+			if cur.Seq > r.Seq || cur.Seq == r.Seq && !cur.Expires.Before(r.Expires) {
+				return ErrStaleRecord
+			}
+		}
+
+		stored := *r
+		stored.deadline = time.Time{}
+		if deadline := now.Add(d.cfg.RecordTTL); stored.Expires.After(deadline) {
+			stored.deadline = deadline
+		}
+
+		d.lock.Lock()
+		if d.records[key] == cur {
+			d.records[key] = &stored
+			d.lock.Unlock()
+			return nil
+		}
+		d.lock.Unlock()
+	}
+}
+
+// record returns the unexpired record stored under key
+func (d *DHT) record(key Key) *Record {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	r, ok := d.records[key]
+	if !ok || r.expired(d.clock.Now()) {
+		return nil
+	}
+	return r
+}
+
+// expire drops the expired records
+func (d *DHT) expire() {
+	now := d.clock.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for key, r := range d.records {
+		if r.expired(now) {
+			delete(d.records, key)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package dht
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+)
+
+// contact is a peer of the routing table
+type contact struct {
+	key    Key
+	record *peer.Record
+}
+
+func (c *contact) addr() string {
+	return c.record.Addrs[0]
+}
+
+// bucket holds up to K contacts sharing the same prefix length with the local key,
+// least recently seen first
+type bucket struct {
+	contacts    []*contact
+	refreshedAt time.Time
+}
+
+func (b *bucket) find(k Key) int {
+	for i, c := range b.contacts {
+		if c.key == k {
+			return i
+		}
+	}
+	return -1
+}
+
+// table is the Kademlia routing table. Bucket i holds the contacts whose key shares
+// exactly i leading bits with the local key
+type table struct {
+	self Key
+	k    int
+
+	lock    sync.Mutex
+	buckets [KeyBits]*bucket
+}
+
+func newTable(self Key, k int, now time.Time) *table {
+	t := &table{self: self, k: k}
+	for i := range t.buckets {
+		t.buckets[i] = &bucket{refreshedAt: now}
+	}
+	return t
+}
+
+func (t *table) bucketIndex(k Key) int {
+	return commonPrefixLen(t.self, k)
+}
+
+// update moves a known contact to the tail of its bucket or adds a new one. If the
+// bucket is full, the least recently seen contact is replaced when it is not alive,
+// otherwise the new contact is dropped. It returns whether the contact is in the
+// table and the replaced contact if any
+func (t *table) update(c *contact, alive func(addr string) bool) (bool, *contact) {
+	i := t.bucketIndex(c.key)
+	if i == KeyBits {
+		return false, nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	b := t.buckets[i]
+	if j := b.find(c.key); j >= 0 {
+		b.contacts = append(append(b.contacts[:j:j], b.contacts[j+1:]...), c)
+		return true, nil
+	}
+	if len(b.contacts) < t.k {
+		b.contacts = append(b.contacts, c)
+		return true, nil
+	}
+
+	oldest := b.contacts[0]
+	if alive(oldest.addr()) {
+		return false, nil
+	}
+	b.contacts = append(b.contacts[1:], c)
+	return true, oldest
+}
+
+// remove removes the contact with key k
+func (t *table) remove(k Key) {
+	i := t.bucketIndex(k)
+	if i == KeyBits {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	b := t.buckets[i]
+	if j := b.find(k); j >= 0 {
+		b.contacts = append(b.contacts[:j:j], b.contacts[j+1:]...)
+	}
+}
+
+// contacts returns all the contacts of the table
+func (t *table) contacts() []*contact {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var all []*contact
+	for _, b := range t.buckets {
+		all = append(all, b.contacts...)
+	}
+	return all
+}
+
+// closest returns up to n contacts closest to k
+func (t *table) closest(k Key, n int) []*contact {
+	all := t.contacts()
+	sortByDistance(all, k)
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+// stale returns the indexes of the buckets not refreshed since interval, up to the
+// farthest non-empty bucket, and marks them refreshed
+func (t *table) stale(now time.Time, interval time.Duration) []int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	last := -1
+	for i, b := range t.buckets {
+		if len(b.contacts) > 0 {
+			last = i
+		}
+	}
+
+	var indexes []int
+	for i := 0; i <= last; i++ {
+		if now.Sub(t.buckets[i].refreshedAt) >= interval {
+			t.buckets[i].refreshedAt = now
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// touch marks the bucket of k refreshed as a lookup of k went through it
+func (t *table) touch(k Key, now time.Time) {
+	i := t.bucketIndex(k)
+	if i == KeyBits {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.buckets[i].refreshedAt = now
+}
+
+func sortByDistance(contacts []*contact, k Key) {
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].key.Distance(k).Less(contacts[j].key.Distance(k))
+	})
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/config"
+)
+
+// backoff tracks the peers that failed to answer and delays their next attempt exponentially
+type backoff struct {
+	cfg   config.Backoff
+	clock clock.Clock
+
+	lock  sync.Mutex
+	peers map[string]*backoffState
+}
+
+type backoffState struct {
+	failures int
+	next     time.Time
+}
+
+func newBackoff(cfg config.Backoff, c clock.Clock) *backoff {
+	return &backoff{
+		cfg:   cfg,
+		clock: c,
+		peers: make(map[string]*backoffState),
+	}
+}
+
+// ready returns true if the peer is not backing off
+func (b *backoff) ready(addr string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s, ok := b.peers[addr]
+	return !ok || !b.clock.Now().Before(s.next)
+}
+
+// failure records a failure of the peer and returns the delay before the next attempt
+func (b *backoff) failure(addr string) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s, ok := b.peers[addr]
+	if !ok {
+		s = &backoffState{}
+		b.peers[addr] = s
+	}
+	s.failures++
+
+	delay := float64(b.cfg.Initial) * math.Pow(b.cfg.Multiplier, float64(s.failures-1))
+	if delay > float64(b.cfg.Max) {
+		delay = float64(b.cfg.Max)
+	}
+	s.next = b.clock.Now().Add(time.Duration(delay))
+	return time.Duration(delay)
+}
+
+// success resets the backoff of the peer
+func (b *backoff) success(addr string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.peers, addr)
+}
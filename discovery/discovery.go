@@ -2,40 +2,126 @@ package discovery
 
 import (
 	"context"
+	"errors"
+	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/mr-shifu/grpc-p2p/config"
 	"github.com/mr-shifu/grpc-p2p/peer"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/connectivity"
 )
 
+const (
+	defaultInterval          = 1 * time.Second
+	defaultConnectTimeout    = 5 * time.Second
+	defaultBackoffInitial    = 1 * time.Second
+	defaultBackoffMax        = 1 * time.Minute
+	defaultBackoffMultiplier = 2
+)
+
+// Settings are the effective settings of the scan discovery after applying defaults
+type Settings struct {
+	Interval       time.Duration
+	Jitter         time.Duration
+	FanOut         int
+	ConnectTimeout time.Duration
+	Backoff        config.Backoff
+	Watch          bool
+}
+
 type Discovery struct {
-	ps     *peer.PeerService
+	ps       *peer.PeerService
+	settings Settings
+	backoff  *backoff
+
+	// watches are the peers watched instead of polled and unsupported the peers
+	// which do not serve watches
+	lock        sync.Mutex
+	watches     map[string]bool
+	unsupported map[string]bool
+	wg          sync.WaitGroup
+
 	logger zerolog.Logger
 }
 
-// NewDiscovery creates a new discovery service
-func NewDiscovery(ps *peer.PeerService, logger zerolog.Logger) *Discovery {
+// NewDiscovery creates a new discovery service. Zero config values are replaced by defaults
+func NewDiscovery(cfg config.Discovery, ps *peer.PeerService, logger zerolog.Logger) *Discovery {
+	settings := Settings{
+		Interval:       cfg.Interval,
+		Jitter:         cfg.Jitter,
+		FanOut:         cfg.FanOut,
+		ConnectTimeout: cfg.ConnectTimeout,
+		Backoff:        cfg.Backoff,
+		Watch:          cfg.Watch,
+	}
+	if settings.Interval <= 0 {
+		settings.Interval = defaultInterval
+	}
+	if settings.Jitter < 0 {
+		settings.Jitter = 0
+	}
+	if settings.FanOut < 0 {
+		settings.FanOut = 0
+	}
+	if settings.ConnectTimeout <= 0 {
+		settings.ConnectTimeout = defaultConnectTimeout
+	}
+	if settings.Backoff.Initial <= 0 {
+		settings.Backoff.Initial = defaultBackoffInitial
+	}
+	if settings.Backoff.Max <= 0 {
+		settings.Backoff.Max = defaultBackoffMax
+	}
+	if settings.Backoff.Multiplier < 1 {
+		settings.Backoff.Multiplier = defaultBackoffMultiplier
+	}
+
 	return &Discovery{
-		ps:     ps,
-		logger: logger,
+		ps:          ps,
+		settings:    settings,
+		backoff:     newBackoff(settings.Backoff, ps.Clock()),
+		watches:     make(map[string]bool),
+		unsupported: make(map[string]bool),
+		logger:      logger,
 	}
 }
 
+// Settings returns the effective settings of the discovery
+func (d *Discovery) Settings() Settings {
+	return d.settings
+}
+
 // scan starts discovery
-// 1. Get adjacent peers from peers in the peerstore
+// 1. Get adjacent peers from up to FanOut random peers in the peerstore
 // 2. Remove duplicate peers
 func (d *Discovery) scan(ctx context.Context) []*peer.Peer {
-	peers := d.ps.GetPeers()
+	peers := d.candidates(d.ps.GetPeers())
 
 	var allpeers []*peer.Peer
 	for _, peer := range peers {
+		if ctx.Err() != nil {
+			break
+		}
+		if d.watching(peer.Addr()) {
+			// the peer pushes its changes and the open watch shows it is alive
+			d.ps.MarkSeen(peer.Addr())
+			d.backoff.success(peer.Addr())
+			continue
+		}
 		neighbors, err := d.ps.GetNeighbors(ctx, peer)
+		if ctx.Err() != nil {
+			// a cancelled call is not a failure of the peer
+			break
+		}
 		if err != nil {
+			d.fail(peer.Addr())
 			continue
 		}
+		d.ps.MarkSeen(peer.Addr())
+		d.backoff.success(peer.Addr())
 		allpeers = append(allpeers, neighbors...)
+		d.watch(ctx, peer)
 	}
 
 	allpeers = removeDuplicatePeers(allpeers)
@@ -43,6 +129,84 @@ func (d *Discovery) scan(ctx context.Context) []*peer.Peer {
 	return allpeers
 }
 
+// candidates returns up to FanOut random connected peers that are not backing off.
+// Peers that are not connected yet are left to refresh
+func (d *Discovery) candidates(peers []*peer.Peer) []*peer.Peer {
+	var ready []*peer.Peer
+	for _, p := range peers {
+		if p.GetState() == peer.Ready && d.backoff.ready(p.Addr()) {
+			ready = append(ready, p)
+		}
+	}
+	if d.settings.FanOut == 0 || len(ready) <= d.settings.FanOut {
+		return ready
+	}
+
+	rand.Shuffle(len(ready), func(i, j int) {
+		ready[i], ready[j] = ready[j], ready[i]
+	})
+	return ready[:d.settings.FanOut]
+}
+
+func (d *Discovery) watching(addr string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.watches[addr]
+}
+
+// watch starts watching the peer if watches are enabled and the peer supports them.
+// Up to FanOut peers are watched. The peer is polled again once the watch ends
+func (d *Discovery) watch(ctx context.Context, p *peer.Peer) {
+	if !d.settings.Watch {
+		return
+	}
+
+	addr := p.Addr()
+	d.lock.Lock()
+	full := d.settings.FanOut > 0 && len(d.watches) >= d.settings.FanOut
+	if d.watches[addr] || d.unsupported[addr] || full {
+		d.lock.Unlock()
+		return
+	}
+	d.watches[addr] = true
+	d.lock.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		err := d.ps.WatchNeighbors(ctx, p, d.addPeers)
+
+		d.lock.Lock()
+		delete(d.watches, addr)
+		if errors.Is(err, peer.ErrWatchUnsupported) {
+			d.unsupported[addr] = true
+		}
+		d.lock.Unlock()
+
+		if ctx.Err() == nil {
+			d.logger.Debug().Err(err).Str("peer", addr).Msg("watch ended")
+		}
+	}()
+}
+
+// fail records a failure of the peer and backs off from it
+func (d *Discovery) fail(addr string) {
+	d.ps.MarkFailed(addr)
+	delay := d.backoff.failure(addr)
+	d.logger.Debug().Str("peer", addr).Dur("backoff", delay).Msg("peer failed")
+}
+
+// nextInterval returns the interval until the next round including a random jitter
+func (d *Discovery) nextInterval() time.Duration {
+	interval := d.settings.Interval
+	if d.settings.Jitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(d.settings.Jitter)))
+	}
+	return interval
+}
+
 func (d *Discovery) addPeers(peers []*peer.Peer) {
 	var list []*peer.Peer
 	for _, p := range peers {
@@ -56,6 +220,13 @@ func (d *Discovery) addPeers(peers []*peer.Peer) {
 	}
 }
 
+// evict removes the peers violating the eviction policy of the peer service
+func (d *Discovery) evict() {
+	for _, p := range d.ps.Evict() {
+		d.logger.Info().Str("peer", p.Addr()).Msg("evicted peer")
+	}
+}
+
 // refresh verifies peers in the peerstore and connects to the peers if not connected
 func (d *Discovery) refresh(ctx context.Context) error {
 	peers := d.ps.GetPeers()
@@ -70,30 +241,26 @@ func (d *Discovery) refresh(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
-		if state != peer.Ready {
+		if state != peer.Ready && d.backoff.ready(p.Addr()) {
 			wg.Add(1)
 			go func(p *peer.Peer) {
 				defer wg.Done()
 
-				// connect to the peer and add to peerstore
-				con, err := d.ps.Connect(p.Addr())
-
-				if err != nil {
-					d.logger.Error().Err(err).Str("peer", p.Addr()).Msg("connection failed")
-					return
-				}
+				// connect to the peer and wait for the connection to be ready
+				ctx, cancel := context.WithTimeout(ctx, d.settings.ConnectTimeout)
+				defer cancel()
 
-				timedout := time.After(5 * time.Second)
-				for con.GetState() != connectivity.Ready {
-					select {
-					case <-timedout:
-						d.logger.Error().Str("peer", p.Addr()).Msg("connection timed out")
+				if _, err := d.ps.WaitReady(ctx, p.Addr()); err != nil {
+					if ctx.Err() == context.Canceled {
 						return
-					default:
-						continue
 					}
+					d.logger.Error().Err(err).Str("peer", p.Addr()).Msg("connection failed")
+					d.fail(p.Addr())
+					return
 				}
 
+				d.ps.MarkSeen(p.Addr())
+				d.backoff.success(p.Addr())
 				d.logger.Info().Str("peer", p.Addr()).Msg("connected")
 			}(p)
 		}
@@ -106,29 +273,41 @@ func (d *Discovery) refresh(ctx context.Context) error {
 // 1. Scans all peers in the peerstore to get adjacent peers
 // 2. Adds all adjacent peers to the peerstore
 // 3. Refreshes peers' connections
+// 4. Evicts unreachable peers
+// Peers supporting it are watched instead of polled if Watch is set.
+// It runs until ctx is done, cancels in-flight requests and returns once they have exited
 func (d *Discovery) Start(ctx context.Context) error {
-	go func() {
-		for {
-			// scan all peers in the peerstore to get adjacent peers
-			peers := d.scan(ctx)
-
-			// update peersetore with adjacent peers
-			d.addPeers(peers)
-
-			// refresh peers' connections
-			if err := d.refresh(ctx); err != nil {
-				d.logger.Error().Err(err).Msg("failed to refresh peers")
-			}
-			// ToDo - make this configurable
-			// sleep for 1 second
-			time.Sleep(1 * time.Second)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	defer d.wg.Wait()
+
+	for {
+		// wait for the next round or the context done signal
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		// scan all peers in the peerstore to get adjacent peers
+		peers := d.scan(ctx)
+		if ctx.Err() != nil {
+			return nil
 		}
-	}()
 
-	// receives context done signal
-	<-ctx.Done()
+		// update peersetore with adjacent peers
+		d.addPeers(peers)
 
-	return nil
+		// refresh peers' connections
+		if err := d.refresh(ctx); err != nil {
+			d.logger.Error().Err(err).Msg("failed to refresh peers")
+		}
+
+		// evict unreachable peers
+		d.evict()
+
+		timer.Reset(d.nextInterval())
+	}
 }
 
 func removeDuplicatePeers(peers []*peer.Peer) []*peer.Peer {
@@ -0,0 +1,44 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/dht"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// StrategyScan periodically asks every known peer for its neighbors
+	StrategyScan = "scan"
+	// StrategySwim runs the SWIM gossip membership protocol
+	StrategySwim = "swim"
+	// StrategyKademlia keeps the peers of the Kademlia DHT routing table only
+	StrategyKademlia = "kademlia"
+)
+
+// Strategy is a peer discovery mechanism keeping the peerstore up to date
+type Strategy interface {
+	// Start runs discovery until ctx is done
+	Start(ctx context.Context) error
+}
+
+// NewStrategy creates the discovery strategy selected in the config
+func NewStrategy(cfg config.Discovery, ps *peer.PeerService, logger zerolog.Logger) (Strategy, error) {
+	switch cfg.Strategy {
+	case "", StrategyScan:
+		return NewDiscovery(cfg, ps, logger), nil
+	case StrategySwim:
+		return NewSwim(cfg.Swim, ps, logger), nil
+	case StrategyKademlia:
+		d, err := dht.New(cfg.Kademlia, ps, logger)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("discovery: unknown strategy %q", cfg.Strategy)
+	}
+}
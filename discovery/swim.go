@@ -0,0 +1,548 @@
+package discovery
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultProbeInterval    = 1 * time.Second
+	defaultProbeTimeout     = 500 * time.Millisecond
+	defaultIndirectProbes   = 3
+	defaultSuspicionTimeout = 5 * time.Second
+	defaultRetransmitMult   = 4
+
+	// maxPiggyback is the maximum number of updates piggybacked on a single message
+	maxPiggyback = 16
+)
+
+type MemberState int
+
+const (
+	// Alive indicates the member acked its last probe
+	Alive MemberState = iota
+	// Suspect indicates the member failed a probe and will be declared dead
+	// unless it refutes the suspicion in time
+	Suspect
+	// Dead indicates the member is removed from the peerstore
+	Dead
+)
+
+// String returns the string representation of the MemberState
+func (s MemberState) String() string {
+	switch s {
+	case Alive:
+		return "ALIVE"
+	case Suspect:
+		return "SUSPECT"
+	case Dead:
+		return "DEAD"
+	default:
+		return "INVALID_STATE"
+	}
+}
+
+// Member is a snapshot of the state of a member as seen by the local node
+type Member struct {
+	Addr        string
+	State       MemberState
+	Incarnation uint64
+}
+
+type member struct {
+	addr        string
+	state       MemberState
+	incarnation uint64
+	record      *peer.Record
+	changedAt   time.Time
+}
+
+// Swim is a discovery strategy implementing the SWIM membership protocol.
+// Every protocol round probes one member directly, falls back to indirect probes
+// through other members, and declares members dead after a suspicion timeout.
+// Membership updates are piggybacked on probe messages. Updates are not signed by
+// their reporter, so members are only suspected and evicted by the local probes
+type Swim struct {
+	cfg   config.Swim
+	ps    *peer.PeerService
+	clock clock.Clock
+
+	lock        sync.Mutex
+	members     map[string]*member
+	incarnation uint64
+	// selfSeq is the sequence number of the last disseminated record of self
+	selfSeq    uint64
+	probeOrder []string
+	probeIndex int
+	broadcasts *broadcastQueue
+
+	logger zerolog.Logger
+
+	p2p_pb.UnimplementedMembershipServer
+}
+
+// NewSwim creates a new SWIM discovery strategy. Zero config values are replaced by defaults
+func NewSwim(cfg config.Swim, ps *peer.PeerService, logger zerolog.Logger) *Swim {
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = defaultProbeInterval
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = defaultProbeTimeout
+	}
+	if cfg.IndirectProbes <= 0 {
+		cfg.IndirectProbes = defaultIndirectProbes
+	}
+	if cfg.SuspicionTimeout <= 0 {
+		cfg.SuspicionTimeout = defaultSuspicionTimeout
+	}
+	if cfg.RetransmitMult <= 0 {
+		cfg.RetransmitMult = defaultRetransmitMult
+	}
+
+	return &Swim{
+		cfg:        cfg,
+		ps:         ps,
+		clock:      ps.Clock(),
+		members:    make(map[string]*member),
+		broadcasts: newBroadcastQueue(),
+		logger:     logger,
+	}
+}
+
+// RegisterService registers the membership rpc service to the server
+func (s *Swim) RegisterService(srv grpc.ServiceRegistrar) {
+	p2p_pb.RegisterMembershipServer(srv, s)
+}
+
+// Start runs protocol rounds every probe interval until ctx is done
+func (s *Swim) Start(ctx context.Context) error {
+	s.refreshSelf()
+
+	ticker := time.NewTicker(s.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.refreshSelf()
+			s.sync()
+			if target, ok := s.nextTarget(); ok {
+				s.probe(ctx, target)
+			}
+			s.expireSuspects()
+		}
+	}
+}
+
+// Members returns the members known by the local node excluding self
+func (s *Swim) Members() []Member {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var members []Member
+	for _, m := range s.members {
+		members = append(members, Member{
+			Addr:        m.addr,
+			State:       m.state,
+			Incarnation: m.incarnation,
+		})
+	}
+	return members
+}
+
+// Ping acks a direct probe
+func (s *Swim) Ping(ctx context.Context, req *p2p_pb.PingRequest) (*p2p_pb.PingResponse, error) {
+	s.apply(req.Updates)
+	return &p2p_pb.PingResponse{
+		Ack:     true,
+		Updates: s.piggyback(),
+	}, nil
+}
+
+// PingReq probes the target on behalf of the caller and acks if the target acked
+func (s *Swim) PingReq(ctx context.Context, req *p2p_pb.PingReqRequest) (*p2p_pb.PingResponse, error) {
+	s.apply(req.Updates)
+	return &p2p_pb.PingResponse{
+		Ack:     s.ping(ctx, req.Target),
+		Updates: s.piggyback(),
+	}, nil
+}
+
+// probe runs the failure detection of a protocol round against the target
+func (s *Swim) probe(ctx context.Context, target string) {
+	if s.ping(ctx, target) {
+		return
+	}
+
+	helpers := s.randomMembers(s.cfg.IndirectProbes, target)
+	acks := make(chan bool, len(helpers))
+	for _, helper := range helpers {
+		go func(helper string) {
+			acks <- s.pingReq(ctx, helper, target)
+		}(helper)
+	}
+	for range helpers {
+		if <-acks {
+			return
+		}
+	}
+
+	s.suspect(target)
+}
+
+// ping sends a direct probe to addr and returns true if it was acked
+func (s *Swim) ping(ctx context.Context, addr string) bool {
+	conn, err := s.ps.Connect(addr)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ProbeTimeout)
+	defer cancel()
+
+	resp, err := p2p_pb.NewMembershipClient(conn).Ping(ctx, &p2p_pb.PingRequest{
+		Updates: s.piggyback(),
+	})
+	if err != nil {
+		return false
+	}
+	s.ps.MarkSeen(addr)
+	s.apply(resp.Updates)
+	return resp.Ack
+}
+
+// pingReq asks helper to probe target and returns true if target acked
+func (s *Swim) pingReq(ctx context.Context, helper string, target string) bool {
+	conn, err := s.ps.Connect(helper)
+	if err != nil {
+		return false
+	}
+
+	// the helper needs a probe timeout to reach the target
+	ctx, cancel := context.WithTimeout(ctx, 2*s.cfg.ProbeTimeout)
+	defer cancel()
+
+	resp, err := p2p_pb.NewMembershipClient(conn).PingReq(ctx, &p2p_pb.PingReqRequest{
+		Target:  target,
+		Updates: s.piggyback(),
+	})
+	if err != nil {
+		return false
+	}
+	s.apply(resp.Updates)
+	return resp.Ack
+}
+
+// sync adds the peers of the peerstore that are not members yet
+func (s *Swim) sync() {
+	peers := s.ps.GetPeers()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, p := range peers {
+		if _, ok := s.members[p.Addr()]; ok {
+			continue
+		}
+		s.members[p.Addr()] = &member{
+			addr:      p.Addr(),
+			state:     Alive,
+			record:    p.Record,
+			changedAt: s.clock.Now(),
+		}
+	}
+}
+
+// nextTarget returns the next member to probe. Members are probed in a random
+// round-robin order so that every member is probed within a bounded time
+func (s *Swim) nextTarget() (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		if s.probeIndex >= len(s.probeOrder) {
+			s.probeOrder = s.probeOrder[:0]
+			for addr, m := range s.members {
+				if m.state != Dead {
+					s.probeOrder = append(s.probeOrder, addr)
+				}
+			}
+			if len(s.probeOrder) == 0 {
+				return "", false
+			}
+			rand.Shuffle(len(s.probeOrder), func(i, j int) {
+				s.probeOrder[i], s.probeOrder[j] = s.probeOrder[j], s.probeOrder[i]
+			})
+			s.probeIndex = 0
+		}
+
+		addr := s.probeOrder[s.probeIndex]
+		s.probeIndex++
+		if m, ok := s.members[addr]; ok && m.state != Dead {
+			return addr, true
+		}
+	}
+}
+
+// randomMembers returns up to k random alive members except the excluded one
+func (s *Swim) randomMembers(k int, exclude string) []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var addrs []string
+	for addr, m := range s.members {
+		if addr != exclude && m.state == Alive {
+			addrs = append(addrs, addr)
+		}
+	}
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	if len(addrs) > k {
+		addrs = addrs[:k]
+	}
+	return addrs
+}
+
+// suspect marks an alive member as suspect and disseminates the suspicion
+func (s *Swim) suspect(addr string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	m, ok := s.members[addr]
+	if !ok || m.state != Alive {
+		return
+	}
+	m.state = Suspect
+	m.changedAt = s.clock.Now()
+	s.broadcasts.push(m.update())
+
+	s.logger.Debug().Str("peer", addr).Msg("peer suspected")
+}
+
+// expireSuspects declares dead the members that did not refute their suspicion in time
+// and forgets dead members once their death had time to be disseminated
+func (s *Swim) expireSuspects() {
+	var dead []string
+
+	s.lock.Lock()
+	now := s.clock.Now()
+	for addr, m := range s.members {
+		switch m.state {
+		case Suspect:
+			if now.Sub(m.changedAt) >= s.cfg.SuspicionTimeout {
+				m.state = Dead
+				m.changedAt = now
+				s.broadcasts.push(m.update())
+				dead = append(dead, addr)
+			}
+		case Dead:
+			if now.Sub(m.changedAt) >= s.retention() {
+				delete(s.members, addr)
+			}
+		}
+	}
+	s.lock.Unlock()
+
+	s.removePeers(dead)
+}
+
+// apply merges the received updates into the local membership following the SWIM
+// precedence rules and queues the updates that changed the local view for dissemination.
+// Suspicions and deaths reported by other members are only used to refute suspicions
+// about self: anyone can send them, so members are never suspected or evicted on hearsay
+func (s *Swim) apply(updates []*p2p_pb.MemberUpdate) {
+	var added []*peer.Record
+
+	s.lock.Lock()
+	for _, u := range updates {
+		if s.ps.IsSelf(u.Address) {
+			// refute suspicions about self by incrementing the incarnation
+			if u.State != p2p_pb.MemberState_ALIVE && u.Incarnation >= s.incarnation {
+				s.incarnation = u.Incarnation + 1
+				s.broadcasts.push(s.selfUpdateLocked())
+			}
+			continue
+		}
+
+		if u.State != p2p_pb.MemberState_ALIVE {
+			continue
+		}
+		m, known := s.members[u.Address]
+		if !known {
+			r := peer.RecordFromPb(u.Record)
+			if r == nil || r.Verify() != nil || !r.HasAddr(u.Address) {
+				continue
+			}
+			// members of other clusters are neither probed nor relayed by an isolated node
+			if !s.ps.Admits(peer.NewPeerFromRecord(r)) {
+				continue
+			}
+			s.members[u.Address] = &member{
+				addr:        u.Address,
+				state:       Alive,
+				incarnation: u.Incarnation,
+				record:      r,
+				changedAt:   s.clock.Now(),
+			}
+			added = append(added, r)
+			s.broadcasts.push(u)
+			continue
+		}
+		if u.Incarnation > m.incarnation {
+			// re-signed records are disseminated with a higher incarnation
+			r := peer.RecordFromPb(u.Record)
+			if r != nil && m.record != nil && r.ID == m.record.ID && r.Seq > m.record.Seq && r.Verify() == nil {
+				m.record = r
+				if m.state != Dead {
+					added = append(added, r)
+				}
+			}
+			if m.state == Dead && m.record != nil {
+				added = append(added, m.record)
+			}
+			m.state = Alive
+			m.incarnation = u.Incarnation
+			m.changedAt = s.clock.Now()
+			s.broadcasts.push(u)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, r := range added {
+		if _, err := s.ps.PutRecord(r); err == nil {
+			s.logger.Info().Str("peer", r.Addrs[0]).Msg("added peer")
+		}
+	}
+}
+
+// removePeers evicts dead members from the peerstore
+func (s *Swim) removePeers(addrs []string) {
+	for _, addr := range addrs {
+		if err := s.ps.EvictPeer(addr); err == nil {
+			s.logger.Info().Str("peer", addr).Msg("removed dead peer")
+		}
+	}
+}
+
+// piggyback returns the updates to attach to an outgoing message
+func (s *Swim) piggyback() []*p2p_pb.MemberUpdate {
+	s.lock.Lock()
+	n := len(s.members) + 1
+	s.lock.Unlock()
+
+	limit := s.cfg.RetransmitMult * int(math.Ceil(math.Log10(float64(n+1))))
+	return s.broadcasts.take(maxPiggyback, limit)
+}
+
+// retention is the time dead members are remembered so that stale alive updates
+// with older incarnations are not accepted again
+func (s *Swim) retention() time.Duration {
+	return 10 * s.cfg.SuspicionTimeout
+}
+
+// refreshSelf disseminates the record of self with a new incarnation once the
+// record was re-signed, e.g. after the local attributes changed
+func (s *Swim) refreshSelf() {
+	seq := s.ps.Self().Record.Seq
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if seq == s.selfSeq {
+		return
+	}
+	if s.selfSeq != 0 {
+		s.incarnation++
+	}
+	s.selfSeq = seq
+	s.broadcasts.push(s.selfUpdateLocked())
+}
+
+func (s *Swim) selfUpdateLocked() *p2p_pb.MemberUpdate {
+	self := s.ps.Self()
+	return &p2p_pb.MemberUpdate{
+		Address:     self.Addr(),
+		State:       p2p_pb.MemberState_ALIVE,
+		Incarnation: s.incarnation,
+		Record:      peer.RecordToPb(self.Record),
+	}
+}
+
+func (m *member) update() *p2p_pb.MemberUpdate {
+	u := &p2p_pb.MemberUpdate{
+		Address:     m.addr,
+		State:       p2p_pb.MemberState(m.state),
+		Incarnation: m.incarnation,
+	}
+	if m.state == Alive {
+		u.Record = peer.RecordToPb(m.record)
+	}
+	return u
+}
+
+type broadcast struct {
+	update    *p2p_pb.MemberUpdate
+	transmits int
+}
+
+// broadcastQueue holds the updates waiting to be piggybacked. An update about a member
+// invalidates the queued update about the same member
+type broadcastQueue struct {
+	lock  sync.Mutex
+	items map[string]*broadcast
+}
+
+func newBroadcastQueue() *broadcastQueue {
+	return &broadcastQueue{
+		items: make(map[string]*broadcast),
+	}
+}
+
+func (q *broadcastQueue) push(u *p2p_pb.MemberUpdate) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.items[u.Address] = &broadcast{update: u}
+}
+
+// take returns up to max updates, preferring the least transmitted ones.
+// Updates transmitted limit times are dropped from the queue
+func (q *broadcastQueue) take(max int, limit int) []*p2p_pb.MemberUpdate {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	items := make([]*broadcast, 0, len(q.items))
+	for _, b := range q.items {
+		items = append(items, b)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].transmits < items[j].transmits
+	})
+	if len(items) > max {
+		items = items[:max]
+	}
+
+	updates := make([]*p2p_pb.MemberUpdate, 0, len(items))
+	for _, b := range items {
+		updates = append(updates, b.update)
+		b.transmits++
+		if b.transmits >= limit {
+			delete(q.items, b.update.Address)
+		}
+	}
+	return updates
+}
@@ -5,6 +5,7 @@ import "errors"
 var (
 	ErrServerGracefullyShutdownTimedout = errors.New("server gracefully shutdown timed out")
 	ErrServerStartServerFailed          = errors.New("server start failed")
-
-	
+	ErrConfigRestartRequired            = errors.New("config change requires a restart")
+	ErrNodeStarted                      = errors.New("node already started")
+	ErrNoConfigFile                     = errors.New("node has no config file to reload")
 )
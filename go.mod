@@ -3,6 +3,7 @@ module github.com/mr-shifu/grpc-p2p
 go 1.21.4
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/rs/zerolog v1.32.0
 	google.golang.org/protobuf v1.31.0
 )
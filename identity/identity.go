@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+)
+
+var (
+	ErrInvalidKeyFile = errors.New("identity: invalid ed25519 private key file")
+)
+
+// Identity is the Ed25519 keypair of a node. The peer ID is derived from the public key
+// so that it stays stable as long as the key does
+type Identity struct {
+	priv ed25519.PrivateKey
+	id   string
+}
+
+// Generate creates a new identity with a random keypair
+func Generate() (*Identity, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return New(priv), nil
+}
+
+// New creates an identity from an existing private key
+func New(priv ed25519.PrivateKey) *Identity {
+	return &Identity{
+		priv: priv,
+		id:   IDFromPublicKey(priv.Public().(ed25519.PublicKey)),
+	}
+}
+
+// FromFile loads the identity stored in the PEM encoded PKCS8 key file at path.
+// If the file does not exist a new identity is generated and stored at path
+func FromFile(path string) (*Identity, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		id, err := Generate()
+		if err != nil {
+			return nil, err
+		}
+		if err := id.Save(path); err != nil {
+			return nil, err
+		}
+		return id, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, ErrInvalidKeyFile
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyFile
+	}
+	return New(priv), nil
+}
+
+// Save writes the private key to path as PEM encoded PKCS8
+func (i *Identity) Save(path string) error {
+	der, err := x509.MarshalPKCS8PrivateKey(i.priv)
+	if err != nil {
+		return err
+	}
+	b := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return os.WriteFile(path, b, 0600)
+}
+
+// ID returns the peer ID derived from the public key
+func (i *Identity) ID() string {
+	return i.id
+}
+
+// PublicKey returns the public key of the identity
+func (i *Identity) PublicKey() ed25519.PublicKey {
+	return i.priv.Public().(ed25519.PublicKey)
+}
+
+// Sign signs msg with the private key of the identity
+func (i *Identity) Sign(msg []byte) []byte {
+	return ed25519.Sign(i.priv, msg)
+}
+
+// IDFromPublicKey derives the peer ID from a public key as the hex encoded SHA-256 digest of the key
+func IDFromPublicKey(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package messaging
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+)
+
+// envelopeDomain separates envelope signatures from any other use of the node key
+const envelopeDomain = "grpc-p2p/envelope"
+
+// envelopeMaxAge is the maximum difference between the timestamp of a signed request
+// and the local clock. Signatures are remembered for this long to reject replays
+const envelopeMaxAge = 1 * time.Minute
+
+// seal sets the record of the local node on the request and signs the request for
+// the peer at dest, so that the sender is authenticated end to end
+func (m *Messenger) seal(env *p2p_pb.Envelope, dest string) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	env.Self = peer.RecordToPb(m.ps.Self().Record)
+	env.Dest = dest
+	env.Timestamp = m.clock.Now().UnixNano()
+	env.Nonce = nonce
+	env.Signature = m.ps.Identity().Sign(envelopePayload(env))
+}
+
+// open checks that the request was signed by the key of the sender record r for the
+// local node within envelopeMaxAge and was not received before
+func (m *Messenger) open(env *p2p_pb.Envelope, r *peer.Record) error {
+	if !ed25519.Verify(r.PublicKey, envelopePayload(env), env.Signature) {
+		return ErrInvalidEnvelope
+	}
+	if !m.ps.IsSelf(env.Dest) {
+		return ErrInvalidEnvelope
+	}
+
+	now := m.clock.Now()
+	ts := time.Unix(0, env.Timestamp)
+	if ts.Before(now.Add(-envelopeMaxAge)) || ts.After(now.Add(envelopeMaxAge)) {
+		return ErrStaleEnvelope
+	}
+	if !m.replays.add(string(env.Signature), ts.Add(envelopeMaxAge), now) {
+		return ErrStaleEnvelope
+	}
+	return nil
+}
+
+// envelopePayload returns the deterministic encoding of the request signed by the sender
+func envelopePayload(env *p2p_pb.Envelope) []byte {
+	var b bytes.Buffer
+	writeField(&b, []byte(envelopeDomain))
+	binary.Write(&b, binary.BigEndian, env.ID)
+	writeField(&b, []byte(env.Protocol))
+	writeField(&b, env.Payload)
+	writeField(&b, []byte(env.Self.GetID()))
+	writeField(&b, []byte(env.Dest))
+	binary.Write(&b, binary.BigEndian, env.Timestamp)
+	writeField(&b, env.Nonce)
+	return b.Bytes()
+}
+
+func writeField(b *bytes.Buffer, v []byte) {
+	binary.Write(b, binary.BigEndian, uint32(len(v)))
+	b.Write(v)
+}
+
+// replayCache remembers the signatures of the requests received until they expire
+type replayCache struct {
+	lock sync.Mutex
+	sigs map[string]time.Time
+	// next is the time the expired signatures are dropped
+	next time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{sigs: make(map[string]time.Time)}
+}
+
+// add records the signature until expiry and returns false if it was already received
+func (c *replayCache) add(sig string, expiry, now time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !now.Before(c.next) {
+		for s, e := range c.sigs {
+			if !now.Before(e) {
+				delete(c.sigs, s)
+			}
+		}
+		c.next = now.Add(envelopeMaxAge)
+	}
+
+	if _, ok := c.sigs[sig]; ok {
+		return false
+	}
+	c.sigs[sig] = expiry
+	return true
+}
@@ -0,0 +1,76 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrEmptyProtocol   = errors.New("messaging: empty protocol")
+	ErrHandlerExists   = errors.New("messaging: handler already registered for protocol")
+	ErrNoHandler       = errors.New("messaging: no handler registered for protocol")
+	ErrPeerNotFound    = errors.New("messaging: peer not found")
+	ErrPeerNotReady    = errors.New("messaging: peer not connected")
+	ErrPeerUnreachable = errors.New("messaging: peer unreachable")
+	ErrPeerShutdown    = errors.New("messaging: peer connection shut down")
+	ErrStopped         = errors.New("messaging: stopped")
+	ErrHopLimit        = errors.New("messaging: hop limit reached")
+	ErrRoutingLoop     = errors.New("messaging: routing loop")
+	ErrInvalidEnvelope = errors.New("messaging: invalid request signature")
+	ErrStaleEnvelope   = errors.New("messaging: request expired or replayed")
+)
+
+// HandlerError is returned when the handler of the remote peer failed
+type HandlerError struct {
+	Protocol string
+	Message  string
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("messaging: handler of %s failed: %s", e.Protocol, e.Message)
+}
+
+// deliveryError maps the error of a request to the peer. Failures of the remote handler
+// are returned as is, while transport failures are mapped from the connectivity state of
+// the peer in the peerstore, e.g. ErrPeerUnreachable if the connection is failing
+func (m *Messenger) deliveryError(addr, protocol string, err error) error {
+	if err == nil || errors.Is(err, ErrStopped) {
+		return err
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unimplemented:
+			return fmt.Errorf("%w: %s", ErrNoHandler, s.Message())
+		case codes.Unknown:
+			return &HandlerError{Protocol: protocol, Message: s.Message()}
+		}
+	}
+
+	state, serr := m.ps.GetState(addr)
+	if serr != nil {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, addr)
+	}
+	switch state {
+	case peer.Ready:
+		return err
+	case peer.TransientFailure:
+		return fmt.Errorf("%w: %s: %v", ErrPeerUnreachable, addr, err)
+	case peer.Shutdown:
+		return fmt.Errorf("%w: %s: %v", ErrPeerShutdown, addr, err)
+	default:
+		return fmt.Errorf("%w: %s: %v", ErrPeerNotReady, addr, err)
+	}
+}
+
+// handlerStatus converts the error of a local handler to the status returned to the peer
+func handlerStatus(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	return status.New(codes.Unknown, err.Error())
+}
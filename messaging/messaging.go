@@ -0,0 +1,175 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Message is a request received from a peer
+type Message struct {
+	Protocol string
+	Payload  []byte
+	// From is the address of the sender taken from its signed record. The request is
+	// signed by the key of the record for the local node and rejected if replayed, so
+	// that a replayed record cannot be used to send requests in the name of its peer.
+	// The record is also checked against the certificate of the sender on TLS connections
+	From string
+}
+
+// Handler handles the requests of a protocol and returns the reply payload.
+// An error is returned to the sender as a HandlerError unless it is a gRPC status
+type Handler func(ctx context.Context, msg *Message) ([]byte, error)
+
+// Messenger sends opaque payloads to peers and dispatches the payloads received from
+// peers to the handler registered for their protocol, e.g. "/chat/1.0.0".
+// Send issues a unary call per request while Request multiplexes the requests to a
+// peer over a single long-lived stream and Relay reaches peers through other peers
+type Messenger struct {
+	cfg   config.Routing
+	ps    *peer.PeerService
+	clock clock.Clock
+	// replays are the signatures of the requests received recently
+	replays *replayCache
+
+	lock     sync.RWMutex
+	handlers map[string]Handler
+	streams  map[string]*stream
+	stopped  bool
+	// done is closed once the messenger is stopped to end the streams opened by peers
+	done chan struct{}
+
+	logger zerolog.Logger
+}
+
+// NewMessenger creates a new messenger sending payloads to the peers of ps.
+// Zero config values are replaced by defaults
+func NewMessenger(cfg config.Routing, ps *peer.PeerService, logger zerolog.Logger) *Messenger {
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = defaultMaxHops
+	}
+
+	return &Messenger{
+		cfg:      cfg,
+		ps:       ps,
+		clock:    ps.Clock(),
+		replays:  newReplayCache(),
+		handlers: make(map[string]Handler),
+		streams:  make(map[string]*stream),
+		done:     make(chan struct{}),
+		logger:   logger,
+	}
+}
+
+// RegisterService registers the messaging rpc service to the server
+func (m *Messenger) RegisterService(srv grpc.ServiceRegistrar) {
+	p2p_pb.RegisterMessagingServer(srv, &server{m: m})
+}
+
+// Start blocks until ctx is done and then closes the streams to and from peers
+func (m *Messenger) Start(ctx context.Context) error {
+	<-ctx.Done()
+	m.stop()
+	return nil
+}
+
+func (m *Messenger) stop() {
+	m.lock.Lock()
+	if m.stopped {
+		m.lock.Unlock()
+		return
+	}
+	m.stopped = true
+	close(m.done)
+	streams := m.streams
+	m.streams = make(map[string]*stream)
+	m.lock.Unlock()
+
+	for _, st := range streams {
+		st.close(ErrStopped)
+	}
+}
+
+// Handle registers the handler of protocol. It returns ErrHandlerExists if the
+// protocol already has a handler
+func (m *Messenger) Handle(protocol string, h Handler) error {
+	if protocol == "" {
+		return ErrEmptyProtocol
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.handlers[protocol]; ok {
+		return ErrHandlerExists
+	}
+	m.handlers[protocol] = h
+	return nil
+}
+
+// RemoveHandler removes the handler of protocol
+func (m *Messenger) RemoveHandler(protocol string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.handlers, protocol)
+}
+
+// Protocols returns the protocols with a registered handler
+func (m *Messenger) Protocols() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var protocols []string
+	for protocol := range m.handlers {
+		protocols = append(protocols, protocol)
+	}
+	return protocols
+}
+
+// Send sends payload to the handler of protocol on the peer with a unary call and
+// returns its reply
+func (m *Messenger) Send(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	if protocol == "" {
+		return nil, ErrEmptyProtocol
+	}
+
+	conn, err := m.ps.ClientConnFor(ctx, addr)
+	if err != nil {
+		return nil, m.deliveryError(addr, protocol, err)
+	}
+	req := &p2p_pb.Envelope{
+		Protocol: protocol,
+		Payload:  payload,
+	}
+	m.seal(req, addr)
+	reply, err := p2p_pb.NewMessagingClient(conn).Send(ctx, req)
+	if err != nil {
+		return nil, m.deliveryError(addr, protocol, err)
+	}
+	return reply.Payload, nil
+}
+
+// dispatch calls the handler of the request protocol with the request of the sender at from
+func (m *Messenger) dispatch(ctx context.Context, req *p2p_pb.Envelope, from string) ([]byte, error) {
+	m.lock.RLock()
+	h, ok := m.handlers[req.Protocol]
+	m.lock.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "no handler for protocol %q", req.Protocol)
+	}
+
+	return h(ctx, &Message{
+		Protocol: req.Protocol,
+		Payload:  req.Payload,
+		From:     from,
+	})
+}
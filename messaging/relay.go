@@ -0,0 +1,128 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultMaxHops = 8
+
+// Relay sends payload to the handler of protocol on the peer at addr like Send, but
+// reaches peers which are not directly connected through the route returned by
+// PeerService.Route. Every hop forwards the request to its own next hop, while the
+// request is signed for the destination so that the hops cannot alter it
+func (m *Messenger) Relay(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	if protocol == "" {
+		return nil, ErrEmptyProtocol
+	}
+
+	route, err := m.ps.Route(addr)
+	if err != nil {
+		return nil, err
+	}
+	if route.Direct() {
+		return m.Send(ctx, addr, protocol, payload)
+	}
+	if len(route.Hops) > m.cfg.MaxHops {
+		return nil, fmt.Errorf("%w: %s is %d hops away", ErrHopLimit, addr, len(route.Hops))
+	}
+
+	env := &p2p_pb.Envelope{
+		Protocol: protocol,
+		Payload:  payload,
+	}
+	m.seal(env, route.Dest)
+	reply, err := m.forward(ctx, route.NextHop(), &p2p_pb.RelayRequest{
+		Dest:     route.Dest,
+		Envelope: env,
+		TTL:      uint32(m.cfg.MaxHops - 1),
+		Path:     []string{m.ps.Self().Addr()},
+	})
+	if err != nil {
+		return nil, m.relayError(route, protocol, err)
+	}
+	return reply.Payload, nil
+}
+
+// relay handles a relay request by dispatching it if the local node is the destination
+// or forwarding it to the next hop of the route to the destination otherwise
+func (m *Messenger) relay(ctx context.Context, req *p2p_pb.RelayRequest) (*p2p_pb.Envelope, error) {
+	if req.Envelope == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing envelope")
+	}
+
+	self := m.ps.Self().Addr()
+	if m.ps.IsSelf(req.Dest) {
+		// the request is delivered by the last hop, so the sender is authenticated by
+		// the signature of the request with the key of its record
+		r := peer.RecordFromPb(req.Envelope.Self)
+		if r == nil || r.Verify() != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid sender record")
+		}
+		if err := m.open(req.Envelope, r); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		payload, err := m.dispatch(ctx, req.Envelope, r.Addrs[0])
+		if err != nil {
+			return nil, handlerStatus(err).Err()
+		}
+		return &p2p_pb.Envelope{ID: req.Envelope.ID, Protocol: req.Envelope.Protocol, Payload: payload}, nil
+	}
+
+	for _, addr := range req.Path {
+		if m.ps.IsSelf(addr) {
+			return nil, status.Errorf(codes.Aborted, "routing loop through %s", self)
+		}
+	}
+	// the TTL set by the sender is capped by the local hop limit
+	ttl := min(req.TTL, uint32(m.cfg.MaxHops))
+	if ttl == 0 {
+		return nil, status.Errorf(codes.ResourceExhausted, "hop limit reached at %s", self)
+	}
+
+	// the peers the request went through are not used as hops again
+	route, err := m.ps.Route(req.Dest, req.Path...)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "no route from %s to %s", self, req.Dest)
+	}
+	return m.forward(ctx, route.NextHop(), &p2p_pb.RelayRequest{
+		Dest:     req.Dest,
+		Envelope: req.Envelope,
+		TTL:      ttl - 1,
+		Path:     append(req.Path, self),
+	})
+}
+
+func (m *Messenger) forward(ctx context.Context, next string, req *p2p_pb.RelayRequest) (*p2p_pb.Envelope, error) {
+	conn, err := m.ps.ClientConnFor(ctx, next)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "next hop %s: %v", next, err)
+	}
+	return p2p_pb.NewMessagingClient(conn).Relay(ctx, req)
+}
+
+// relayError maps the failures of the hops of the route. Other errors are mapped
+// from the connectivity state of the next hop
+func (m *Messenger) relayError(route *peer.Route, protocol string, err error) error {
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Aborted:
+			return fmt.Errorf("%w: %s", ErrRoutingLoop, s.Message())
+		case codes.ResourceExhausted:
+			return fmt.Errorf("%w: %s", ErrHopLimit, s.Message())
+		case codes.NotFound:
+			return fmt.Errorf("%w: %s", peer.ErrNoRoute, s.Message())
+		}
+	}
+	err = m.deliveryError(route.NextHop(), protocol, err)
+	if errors.Is(err, ErrPeerNotFound) {
+		return fmt.Errorf("%w: %s", peer.ErrNoRoute, route)
+	}
+	return err
+}
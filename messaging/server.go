@@ -0,0 +1,128 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// server implements the messaging rpc service by dispatching the requests of peers
+// to the handlers of the messenger
+type server struct {
+	m *Messenger
+
+	p2p_pb.UnimplementedMessagingServer
+}
+
+// Send handles a unary request of a peer
+func (s *server) Send(ctx context.Context, req *p2p_pb.Envelope) (*p2p_pb.Envelope, error) {
+	sender, err := s.m.verifyCaller(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	payload, err := s.m.dispatch(ctx, req, sender.Addrs[0])
+	if err != nil {
+		return nil, handlerStatus(err).Err()
+	}
+	return &p2p_pb.Envelope{ID: req.ID, Protocol: req.Protocol, Payload: payload}, nil
+}
+
+// Relay handles a request relayed by a peer
+func (s *server) Relay(ctx context.Context, req *p2p_pb.RelayRequest) (*p2p_pb.Envelope, error) {
+	return s.m.relay(ctx, req)
+}
+
+// Stream handles the requests of a peer concurrently and replies on the stream
+// with the ID of each request until the peer closes the stream or the messenger stops.
+// The first request carries the record of the peer sending all the requests of the stream
+// and is signed by its key. The pending requests are canceled and awaited before the stream ends
+func (s *server) Stream(srv p2p_pb.Messaging_StreamServer) error {
+	first, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	sender, err := s.m.verifyCaller(srv.Context(), first)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(srv.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	var sendLock sync.Mutex
+	reply := func(env *p2p_pb.Envelope) {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		srv.Send(env)
+	}
+	handle := func(req *p2p_pb.Envelope) {
+		defer wg.Done()
+
+		env := &p2p_pb.Envelope{ID: req.ID, Protocol: req.Protocol}
+		payload, err := s.m.dispatch(ctx, req, sender.Addrs[0])
+		if err != nil {
+			st := handlerStatus(err)
+			env.Code = uint32(st.Code())
+			env.Error = st.Message()
+		} else {
+			env.Payload = payload
+		}
+		if ctx.Err() == nil {
+			reply(env)
+		}
+	}
+
+	// the requests are received by a separate goroutine so that the stream ends
+	// when the messenger stops, while the handlers are started here to be awaited
+	reqs := make(chan *p2p_pb.Envelope)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := srv.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go handle(first)
+	for {
+		select {
+		case req := <-reqs:
+			wg.Add(1)
+			go handle(req)
+		case err := <-errs:
+			return err
+		case <-s.m.done:
+			return nil
+		}
+	}
+}
+
+// verifyCaller returns the record of the caller sending the signed request req after
+// verifying the record and the signature of the request
+func (m *Messenger) verifyCaller(ctx context.Context, req *p2p_pb.Envelope) (*peer.Record, error) {
+	r, err := peer.VerifyCaller(ctx, req.Self)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.open(req, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
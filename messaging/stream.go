@@ -0,0 +1,212 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stream is the long-lived stream to a peer multiplexing the requests sent with
+// Request. Replies are matched to their request by ID
+type stream struct {
+	addr string
+	// seal signs the first request for the peer
+	seal   func(env *p2p_pb.Envelope)
+	cs     p2p_pb.Messaging_StreamClient
+	cancel context.CancelFunc
+
+	sendLock sync.Mutex
+	// introduced is true once the signed record of the local node was sent on the stream
+	introduced bool
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *p2p_pb.Envelope
+	// err is the reason the stream was closed. It is nil while the stream is open
+	err error
+}
+
+// Request sends payload to the handler of protocol on the peer over the stream to
+// the peer and returns its reply. The stream is opened on the first request
+func (m *Messenger) Request(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	if protocol == "" {
+		return nil, ErrEmptyProtocol
+	}
+
+	st, err := m.stream(ctx, addr)
+	if err != nil {
+		return nil, m.deliveryError(addr, protocol, err)
+	}
+
+	id, replies, err := st.register()
+	if err != nil {
+		return nil, m.deliveryError(addr, protocol, err)
+	}
+	defer st.unregister(id)
+
+	err = st.send(&p2p_pb.Envelope{
+		ID:       id,
+		Protocol: protocol,
+		Payload:  payload,
+	})
+	if err != nil {
+		st.close(err)
+		return nil, m.deliveryError(addr, protocol, err)
+	}
+
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return nil, m.deliveryError(addr, protocol, st.closeErr())
+		}
+		if reply.Error != "" {
+			return nil, m.deliveryError(addr, protocol, status.Error(codes.Code(reply.Code), reply.Error))
+		}
+		return reply.Payload, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// stream returns the open stream to the peer or opens a new one
+func (m *Messenger) stream(ctx context.Context, addr string) (*stream, error) {
+	m.lock.RLock()
+	st, ok := m.streams[addr]
+	stopped := m.stopped
+	m.lock.RUnlock()
+	if stopped {
+		return nil, ErrStopped
+	}
+	if ok {
+		return st, nil
+	}
+
+	conn, err := m.ps.ClientConnFor(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	// the stream outlives the request opening it and is closed when the messenger stops
+	sctx, cancel := context.WithCancel(context.Background())
+	cs, err := p2p_pb.NewMessagingClient(conn).Stream(sctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	st = &stream{
+		addr:    addr,
+		seal:    func(env *p2p_pb.Envelope) { m.seal(env, addr) },
+		cs:      cs,
+		cancel:  cancel,
+		pending: make(map[uint64]chan *p2p_pb.Envelope),
+	}
+
+	m.lock.Lock()
+	if m.stopped {
+		m.lock.Unlock()
+		st.close(ErrStopped)
+		return nil, ErrStopped
+	}
+	// another request opened a stream concurrently
+	if current, ok := m.streams[addr]; ok {
+		m.lock.Unlock()
+		st.close(nil)
+		return current, nil
+	}
+	m.streams[addr] = st
+	m.lock.Unlock()
+
+	go m.receive(st)
+	return st, nil
+}
+
+// receive dispatches the replies of the stream until it fails and then forgets the stream
+func (m *Messenger) receive(st *stream) {
+	for {
+		reply, err := st.cs.Recv()
+		if err != nil {
+			st.close(err)
+			break
+		}
+		st.deliver(reply)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.streams[st.addr] == st {
+		delete(m.streams, st.addr)
+	}
+}
+
+// register allocates the ID of a request and returns the channel receiving its reply
+func (st *stream) register() (uint64, chan *p2p_pb.Envelope, error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if st.err != nil {
+		return 0, nil, st.err
+	}
+	st.nextID++
+	replies := make(chan *p2p_pb.Envelope, 1)
+	st.pending[st.nextID] = replies
+	return st.nextID, replies, nil
+}
+
+func (st *stream) unregister(id uint64) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	delete(st.pending, id)
+}
+
+func (st *stream) deliver(reply *p2p_pb.Envelope) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if replies, ok := st.pending[reply.ID]; ok {
+		replies <- reply
+		delete(st.pending, reply.ID)
+	}
+}
+
+// send sends the request on the stream. The first request is signed and carries the
+// record of the local node identifying the sender of all the requests of the stream
+func (st *stream) send(env *p2p_pb.Envelope) error {
+	st.sendLock.Lock()
+	defer st.sendLock.Unlock()
+
+	if !st.introduced {
+		st.seal(env)
+		st.introduced = true
+	}
+	return st.cs.Send(env)
+}
+
+// close closes the stream and fails its pending requests with err
+func (st *stream) close(err error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if st.err != nil {
+		return
+	}
+	if err == nil {
+		err = ErrStopped
+	}
+	st.err = err
+	for id, replies := range st.pending {
+		close(replies)
+		delete(st.pending, id)
+	}
+	st.cancel()
+}
+
+func (st *stream) closeErr() error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	return st.err
+}
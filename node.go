@@ -2,13 +2,20 @@ package p2p
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"sync"
 	"time"
 
+	"github.com/mr-shifu/grpc-p2p/clock"
 	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/dht"
 	"github.com/mr-shifu/grpc-p2p/discovery"
+	"github.com/mr-shifu/grpc-p2p/messaging"
 	"github.com/mr-shifu/grpc-p2p/peer"
+	"github.com/mr-shifu/grpc-p2p/pubsub"
 	"github.com/mr-shifu/grpc-p2p/rpc"
+	"github.com/mr-shifu/grpc-p2p/transport"
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -22,55 +29,163 @@ type Node struct {
 	// Node local config including name, cluster name, address to listen for incoming connections
 	local *config.Peer
 
+	// cfgpath is the config file watched for changes and cfg the running config
+	cfgpath string
+	cfg     *config.Config
+
+	// listener is the listener served by the node. The node listens on its local
+	// address if nil
+	listener net.Listener
+
 	//
 	peerService *peer.PeerService
 
+	// discovery strategy keeping the peerstore up to date while the node is started
+	discovery discovery.Strategy
+
+	// pubsub delivers the messages published on the topics subscribed by the node
+	pubsub *pubsub.Pubsub
+
+	// rpcService serves the peers of the node to its neighbors
+	rpcService *rpc.RpcService
+
+	// messenger exchanges direct requests with peers and dispatches the requests of
+	// peers to the handlers registered on the node
+	messenger *messaging.Messenger
+
+	// cancel stops the node started by Start
+	lock    sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	// discoveryDone is closed once discovery has exited
+	discoveryDone chan struct{}
+
+	stopOnce sync.Once
+	stopErr  error
+
 	// Logger to use for debug logging
 	logger zerolog.Logger
 }
 
-// NewNode creates a new node with the given address and logger
-func NewNode(cfgpath string, logger zerolog.Logger) *Node {
-	cfg, err := config.FromFile(cfgpath)
+// NewNode creates a new node from the config file at cfgpath overlaid with the
+// P2P_* environment variables. The node reloads the config file when it changes.
+// It returns an error if the config is invalid or the node services cannot be created
+func NewNode(cfgpath string, logger zerolog.Logger) (*Node, error) {
+	cfg, prov, err := config.NewLoader().WithFile(cfgpath).Load()
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	logger.Debug().Msgf("config loaded:\n%s", prov)
+
+	return NewNodeFromConfig(cfg, WithLogger(logger), withConfigFile(cfgpath))
+}
+
+// NewNodeFromConfig creates a new node from cfg customized by opts.
+// It returns an error if the config is invalid or the node services cannot be created
+func NewNodeFromConfig(cfg *config.Config, opts ...Option) (*Node, error) {
+	o := &options{
+		clock:  clock.Real,
+		logger: zerolog.Nop(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	logger := o.logger
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	// create new grpc server
-	opts := []grpc.ServerOption{}
-	server := grpc.NewServer(opts...)
+	// create new grpc server secured by the configured transport credentials
+	creds, err := transport.ServerOption(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server credentials: %w", err)
+	}
+	server := grpc.NewServer(append([]grpc.ServerOption{creds}, o.serverOpts...)...)
 
 	// instantiate a new peer service
-	ps := peer.NewPeerService(cfg, logger)
+	psOpts := []peer.Option{peer.WithDialOptions(o.dialOpts...), peer.WithClock(o.clock)}
+	if o.store != nil {
+		psOpts = append(psOpts, peer.WithStore(o.store))
+	}
+	ps, err := peer.NewPeerService(cfg, logger, psOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer service: %w", err)
+	}
 
-	//
-	ds := discovery.NewDiscovery(ps, logger)
-	go ds.Start(context.Background())
+	// instantiate the discovery strategy and register its rpc service if any
+	var ds discovery.Strategy
+	if o.strategy != nil {
+		ds, err = o.strategy(ps)
+	} else {
+		ds, err = discovery.NewStrategy(cfg.Discovery, ps, logger)
+	}
+	if err != nil {
+		ps.Close()
+		return nil, fmt.Errorf("failed to create discovery: %w", err)
+	}
+	if svc, ok := ds.(interface{ RegisterService(grpc.ServiceRegistrar) }); ok {
+		svc.RegisterService(server)
+	}
 
 	// instantiate a new rpc service and register rpc service to server
-	rs := rpc.NewRpcService(ps, logger)
+	rs := rpc.NewRpcService(cfg.Exchange, ps, logger)
 	rs.RegisterService(server)
 
+	// instantiate a new pubsub and register its rpc service to server
+	pub := pubsub.NewPubsub(cfg.Pubsub, ps, logger)
+	pub.RegisterService(server)
+
+	// instantiate a new messenger and register its rpc service to server
+	msgr := messaging.NewMessenger(cfg.Routing, ps, logger)
+	msgr.RegisterService(server)
+
 	// enable rpc reflection
 	reflection.Register(server)
 
 	return &Node{
-		local:       &cfg.Local,
-		server:      server,
-		peerService: ps,
-		logger:      logger,
-	}
+		local:         &cfg.Local,
+		cfgpath:       o.cfgpath,
+		cfg:           cfg,
+		listener:      o.listener,
+		server:        server,
+		peerService:   ps,
+		rpcService:    rs,
+		discovery:     ds,
+		pubsub:        pub,
+		messenger:     msgr,
+		discoveryDone: make(chan struct{}),
+		logger:        logger,
+	}, nil
 }
 
 // Start starts the node and listens for incoming connections
 // It returns an error if the node fails to start
+// It runs discovery until the node is stopped
 // It waits for receiving a signal from ctx to stop server grcefully
 // It forces server to stop if gracefully shutdown failed ad returns an error
 func (n *Node) Start(ctx context.Context) error {
-	ln, err := net.Listen("tcp", n.Self().Addr)
-	if err != nil {
-		return err
+	n.lock.Lock()
+	if n.started {
+		n.lock.Unlock()
+		return ErrNodeStarted
 	}
+	// the node is only started once it listens so that a failed start can be retried
+	ln := n.listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", n.local.Addr); err != nil {
+			n.lock.Unlock()
+			return err
+		}
+	}
+	n.started = true
+	n.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	n.lock.Lock()
+	n.cancel = cancel
+	n.lock.Unlock()
 
 	group, gCtx := errgroup.WithContext(ctx)
 	group.Go(func() error {
@@ -81,6 +196,22 @@ func (n *Node) Start(ctx context.Context) error {
 		}
 		return nil
 	})
+	group.Go(func() error {
+		defer close(n.discoveryDone)
+		return n.discovery.Start(gCtx)
+	})
+	group.Go(func() error {
+		return n.rpcService.Start(gCtx)
+	})
+	group.Go(func() error {
+		return n.pubsub.Start(gCtx)
+	})
+	group.Go(func() error {
+		return n.messenger.Start(gCtx)
+	})
+	group.Go(func() error {
+		return n.watchConfig(gCtx)
+	})
 	group.Go(func() error {
 		<-gCtx.Done()
 		return n.Stop()
@@ -88,9 +219,27 @@ func (n *Node) Start(ctx context.Context) error {
 	return group.Wait()
 }
 
-// Stop first tries to gracefully shutdown the server and if it timed out then
-// it forces the server to stop and returns an error
+// Stop first stops discovery and waits for it to exit, then tries to gracefully
+// shutdown the server and if it timed out then it forces the server to stop and
+// returns an error. Peer connections are closed in any case
 func (n *Node) Stop() error {
+	n.stopOnce.Do(func() {
+		n.stopErr = n.stop()
+	})
+	return n.stopErr
+}
+
+func (n *Node) stop() error {
+	n.lock.Lock()
+	cancel := n.cancel
+	n.lock.Unlock()
+
+	// stop discovery first so that no new connection is dialed during shutdown
+	if cancel != nil {
+		cancel()
+		<-n.discoveryDone
+	}
+
 	n.logger.Debug().Msg("Gracefully Shutdown of Node")
 
 	stopped := make(chan struct{})
@@ -100,26 +249,101 @@ func (n *Node) Stop() error {
 	}()
 
 	timeout := time.NewTimer(5 * time.Second)
+	defer timeout.Stop()
 
+	var err error
 	select {
 	case <-timeout.C:
 		n.logger.Debug().Msg("Forcing Shutdown of Node")
 		n.server.Stop()
-		return ErrServerGracefullyShutdownTimedout
+		err = ErrServerGracefullyShutdownTimedout
 	case <-stopped:
 		n.logger.Debug().Msg("Node Gracefully Shutdown Successfully")
-		return nil
 	}
+
+	if derr := n.peerService.DisconnectAll(); derr != nil && err == nil {
+		err = derr
+	}
+	if cerr := n.peerService.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
 }
 
 func (n *Node) PeerService() *peer.PeerService {
 	return n.peerService
 }
 
+var _ grpc.ServiceRegistrar = (*Node)(nil)
+
+// RegisterService registers an application service on the node server so that
+// generated helpers can be used, e.g. pb.RegisterEchoServer(node, impl).
+// Like grpc.Server, services must be registered before Start: it panics with
+// ErrNodeStarted otherwise
+func (n *Node) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.started {
+		panic(fmt.Sprintf("%v: cannot register service %s", ErrNodeStarted, desc.ServiceName))
+	}
+	n.server.RegisterService(desc, impl)
+}
+
+// Subscribe returns a channel receiving the messages published on topic by any node
+// until ctx is done
+func (n *Node) Subscribe(ctx context.Context, topic string) (<-chan *pubsub.Message, error) {
+	return n.pubsub.Subscribe(ctx, topic)
+}
+
+// Publish publishes data on topic to the subscribers of every node
+func (n *Node) Publish(ctx context.Context, topic string, data []byte) error {
+	return n.pubsub.Publish(ctx, topic, data)
+}
+
+func (n *Node) Pubsub() *pubsub.Pubsub {
+	return n.pubsub
+}
+
+// Handle registers the handler of the requests sent by peers to protocol.
+// Unlike services, handlers can be registered while the node is started
+func (n *Node) Handle(protocol string, h messaging.Handler) error {
+	return n.messenger.Handle(protocol, h)
+}
+
+// Send sends payload to the handler of protocol on the peer at addr and returns its reply
+func (n *Node) Send(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	return n.messenger.Send(ctx, addr, protocol, payload)
+}
+
+// Request is like Send but multiplexes the requests to the peer over a long-lived stream
+func (n *Node) Request(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	return n.messenger.Request(ctx, addr, protocol, payload)
+}
+
+// Relay is like Send but reaches peers which are not directly connected through other peers
+func (n *Node) Relay(ctx context.Context, addr, protocol string, payload []byte) ([]byte, error) {
+	return n.messenger.Relay(ctx, addr, protocol, payload)
+}
+
+func (n *Node) Messenger() *messaging.Messenger {
+	return n.messenger
+}
+
+// DHT returns the Kademlia DHT of the node to put and get records. It is nil unless
+// the discovery strategy is kademlia
+func (n *Node) DHT() *dht.DHT {
+	d, _ := n.discovery.(*dht.DHT)
+	return d
+}
+
 func (n *Node) Server() *grpc.Server {
 	return n.server
 }
 
 func (n *Node) Self() *config.Peer {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
 	return n.local
 }
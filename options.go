@@ -0,0 +1,91 @@
+package p2p
+
+import (
+	"net"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/discovery"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// Option configures a node created by NewNodeFromConfig
+type Option func(*options)
+
+// StrategyFactory creates the discovery strategy of a node from its peer service
+type StrategyFactory func(ps *peer.PeerService) (discovery.Strategy, error)
+
+type options struct {
+	serverOpts []grpc.ServerOption
+	dialOpts   []grpc.DialOption
+	listener   net.Listener
+	store      peer.Store
+	strategy   StrategyFactory
+	clock      clock.Clock
+	logger     zerolog.Logger
+
+	// cfgpath is the config file watched for changes
+	cfgpath string
+}
+
+// WithServerOptions appends options to the grpc server, e.g. interceptors.
+// The transport credentials of the config are applied first
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// WithDialOptions appends options used to dial peers, e.g. interceptors.
+// The transport credentials of the config are applied first
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithListener makes the node serve on the given listener instead of listening on
+// the local address. The local address must still be the address peers dial
+func WithListener(ln net.Listener) Option {
+	return func(o *options) {
+		o.listener = ln
+	}
+}
+
+// WithStore makes the node use the given peerstore instead of the store of the config
+func WithStore(store peer.Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithDiscovery makes the node use the strategy created by f instead of the strategy
+// of the config. The strategy's rpc service is registered if it has a
+// RegisterService(grpc.ServiceRegistrar) method
+func WithDiscovery(f StrategyFactory) Option {
+	return func(o *options) {
+		o.strategy = f
+	}
+}
+
+// WithClock makes the node use the given clock instead of the wall clock
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithLogger sets the logger of the node. Nothing is logged by default
+func WithLogger(logger zerolog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// withConfigFile makes the node reload its config from path
+func withConfigFile(path string) Option {
+	return func(o *options) {
+		o.cfgpath = path
+	}
+}
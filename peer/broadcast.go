@@ -0,0 +1,112 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var (
+	ErrQuorumNotReached = errors.New("peer: broadcast quorum not reached")
+)
+
+// BroadcastOptions selects the peers called by Broadcast and bounds the broadcast
+type BroadcastOptions struct {
+	// Attributes selects the peers having all the attributes. All peers are called if empty
+	Attributes map[string]string
+	// Concurrency limits the number of concurrent calls. It is unlimited if zero
+	Concurrency int
+	// Timeout bounds the whole broadcast in addition to the deadline of the context.
+	// There is no timeout if zero
+	Timeout time.Duration
+	// Quorum returns as soon as this many calls succeeded and cancels the remaining calls.
+	// All calls complete if zero
+	Quorum int
+}
+
+// BroadcastFunc is the call made on every selected peer over the pooled connection to the peer
+type BroadcastFunc func(ctx context.Context, conn *grpc.ClientConn) (interface{}, error)
+
+// BroadcastResult is the outcome of the call made on a peer
+type BroadcastResult struct {
+	Peer  *Peer
+	Value interface{}
+	Err   error
+}
+
+// Broadcast calls fn on every peer selected by opts and returns the result of every
+// peer in the order of GetPeersWithAttributes. Calls cancelled once the quorum was
+// reached report the context error. It returns ErrQuorumNotReached if a quorum is set
+// and fewer calls succeeded
+func (ps *PeerService) Broadcast(ctx context.Context, opts BroadcastOptions, fn BroadcastFunc) ([]BroadcastResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	peers := ps.GetPeersWithAttributes(opts.Attributes)
+	results := make([]BroadcastResult, len(peers))
+
+	var sem chan struct{}
+	if opts.Concurrency > 0 {
+		sem = make(chan struct{}, opts.Concurrency)
+	}
+
+	var lock sync.Mutex
+	succeeded := 0
+
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		results[i].Peer = p
+		wg.Add(1)
+		go func(r *BroadcastResult) {
+			defer wg.Done()
+
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					r.Err = ctx.Err()
+					return
+				}
+			}
+
+			r.Value, r.Err = ps.call(ctx, r.Peer.Addr(), fn)
+			if r.Err != nil {
+				return
+			}
+
+			lock.Lock()
+			succeeded++
+			if opts.Quorum > 0 && succeeded >= opts.Quorum {
+				cancel()
+			}
+			lock.Unlock()
+		}(&results[i])
+	}
+	wg.Wait()
+
+	if opts.Quorum > 0 && succeeded < opts.Quorum {
+		return results, ErrQuorumNotReached
+	}
+	return results, nil
+}
+
+// call makes fn on the ready connection to the peer
+func (ps *PeerService) call(ctx context.Context, addr string, fn BroadcastFunc) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn, err := ps.ClientConnFor(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return fn(ctx, conn)
+}
@@ -0,0 +1,98 @@
+package peer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// maxChanges is the number of changes kept to serve incremental peer exchanges.
+// Peers lagging further behind receive a full snapshot
+const maxChanges = 4096
+
+// Change is a change of a peer in the peerstore
+type Change struct {
+	// Version is the version of the peerstore after the change
+	Version uint64
+	Addr    string
+	Removed bool
+}
+
+// changeLog numbers the changes of the peerstore so that neighbors can request the
+// changes since the last version they received. The epoch identifies the sequence
+// of versions, which restarts with the process
+type changeLog struct {
+	lock    sync.Mutex
+	epoch   string
+	version uint64
+	changes []Change
+}
+
+func newChangeLog() *changeLog {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &changeLog{epoch: hex.EncodeToString(b)}
+}
+
+// record appends a change of the peer at addr and returns the new version
+func (l *changeLog) record(addr string, removed bool) uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.version++
+	l.changes = append(l.changes, Change{Version: l.version, Addr: addr, Removed: removed})
+	if len(l.changes) > maxChanges {
+		l.changes = append([]Change(nil), l.changes[len(l.changes)-maxChanges:]...)
+	}
+	return l.version
+}
+
+func (l *changeLog) current() (string, uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.epoch, l.version
+}
+
+// since returns the latest change of every peer changed after version, oldest first.
+// It returns false if the changes since version are not kept anymore or version is
+// not a version of the log
+func (l *changeLog) since(version uint64) ([]Change, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if version > l.version {
+		return nil, false
+	}
+	if len(l.changes) > 0 && version+1 < l.changes[0].Version {
+		return nil, false
+	}
+
+	// walk the log backwards to keep the latest change of every peer only
+	var changes []Change
+	seen := make(map[string]bool)
+	for i := len(l.changes) - 1; i >= 0 && l.changes[i].Version > version; i-- {
+		c := l.changes[i]
+		if seen[c.Addr] {
+			continue
+		}
+		seen[c.Addr] = true
+		changes = append(changes, c)
+	}
+	for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+		changes[i], changes[j] = changes[j], changes[i]
+	}
+	return changes, true
+}
+
+// Version returns the epoch and the current version of the peerstore changes
+func (ps *PeerService) Version() (string, uint64) {
+	return ps.changes.current()
+}
+
+// Changes returns the latest change of every peer changed after version, oldest first.
+// It returns false if the changes since version are not known, in which case the
+// caller needs a full snapshot of the peerstore
+func (ps *PeerService) Changes(version uint64) ([]Change, bool) {
+	return ps.changes.since(version)
+}
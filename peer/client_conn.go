@@ -0,0 +1,48 @@
+package peer
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ClientConnFor returns the pooled connection to the peer once it is ready.
+// The connection is shared with the discovery layer and must not be closed by the caller
+func (ps *PeerService) ClientConnFor(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	return ps.WaitReady(ctx, addr)
+}
+
+// Dial dials the peer at addr with the dial options of the peer service without adding
+// it to the peerstore, e.g. to query a peer once. The caller closes the connection
+func (ps *PeerService) Dial(addr string) (*grpc.ClientConn, error) {
+	return grpc.Dial(addr, ps.dialOpts...)
+}
+
+// Client returns a connection to the peer to create typed clients of application
+// services, e.g. pb.NewEchoClient(ps.Client(addr)). Every call waits for the pooled
+// connection to the peer to be ready within the deadline of the call
+func (ps *PeerService) Client(addr string) grpc.ClientConnInterface {
+	return &peerConn{ps: ps, addr: addr}
+}
+
+// peerConn resolves the pooled connection to a peer on every call
+type peerConn struct {
+	ps   *PeerService
+	addr string
+}
+
+func (c *peerConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := c.ps.ClientConnFor(ctx, c.addr)
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+func (c *peerConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := c.ps.ClientConnFor(ctx, c.addr)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
@@ -0,0 +1,134 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+)
+
+var (
+	ErrPeerNotAdmitted = errors.New("peerstore: peer is not a member of the cluster")
+)
+
+// membership decides which peers are accepted into the peerstore based on their cluster
+type membership struct {
+	// cluster is the name of the local cluster
+	cluster string
+	// isolated only admits peers of the local cluster and the gateways
+	isolated bool
+	// gateways are the normalized addresses of the peers admitted from any cluster
+	gateways map[string]bool
+
+	// relayed are the addresses of the peers of other clusters served by a gateway
+	lock    sync.RWMutex
+	relayed map[string]bool
+}
+
+func newMembership(local config.Peer, cfg config.Cluster) *membership {
+	m := &membership{
+		cluster:  local.ClusterName,
+		isolated: cfg.Isolated,
+		gateways: make(map[string]bool),
+		relayed:  make(map[string]bool),
+	}
+	for _, addr := range cfg.Gateways {
+		if addr, err := validatePeerAddr(addr); err == nil {
+			m.gateways[addr] = true
+		}
+	}
+	return m
+}
+
+func (m *membership) isGateway(addr string) bool {
+	addr, err := validatePeerAddr(addr)
+	return err == nil && m.gateways[addr]
+}
+
+// admits returns true if the peer may be added to the peerstore. An isolated node
+// admits the peers of other clusters only through the gateways
+func (m *membership) admits(p *Peer) bool {
+	if !m.isolated || m.isGateway(p.Addr()) {
+		return true
+	}
+	cluster := p.ClusterName
+	if p.Record != nil {
+		cluster = p.Record.ClusterName
+	}
+	if cluster == m.cluster {
+		return true
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	addr, err := validatePeerAddr(p.Addr())
+	return err == nil && m.relayed[addr]
+}
+
+// relay admits the peers of other clusters served by the gateway at addr
+func (m *membership) relay(addr string, peers []*Peer) {
+	if !m.isolated || !m.isGateway(addr) {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, p := range peers {
+		if a, err := validatePeerAddr(p.Addr()); err == nil {
+			m.relayed[a] = true
+		}
+	}
+}
+
+// forget stops admitting the peer at addr through the gateways until it is served again
+func (m *membership) forget(addr string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.relayed, addr)
+}
+
+// ClusterName returns the name of the cluster of the local node
+func (ps *PeerService) ClusterName() string {
+	return ps.membership.cluster
+}
+
+// IsGateway returns true if the peer is configured as a gateway between clusters
+func (ps *PeerService) IsGateway(addr string) bool {
+	return ps.membership.isGateway(addr)
+}
+
+// Admits returns true if the peer is accepted by the cluster membership of the local node.
+// All peers are accepted unless the node is isolated, in which case the peers of other
+// clusters are only accepted once a gateway served them
+func (ps *PeerService) Admits(p *Peer) bool {
+	return ps.membership.admits(p)
+}
+
+// AdmitCaller returns the record of the caller of an incoming rpc verified by
+// VerifyCaller if the caller is admitted by the cluster membership
+func (ps *PeerService) AdmitCaller(ctx context.Context, pb *p2p_pb.PeerRecord) (*Record, error) {
+	r, err := VerifyCaller(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	if !ps.Admits(NewPeerFromRecord(r)) {
+		return nil, ErrPeerNotAdmitted
+	}
+	return r, nil
+}
+
+// GetPeersInCluster returns the peers which are members of the given cluster
+func (ps *PeerService) GetPeersInCluster(name string) []*Peer {
+	var peers []*Peer
+	for _, p := range ps.peerstore.GetPeers() {
+		if p.InCluster(name) {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
@@ -0,0 +1,163 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+var (
+	ErrConnectionShutdown = errors.New("peer: connection is shut down")
+)
+
+// maxTransitions is the number of connection state transitions kept per peer
+const maxTransitions = 16
+
+// Transition is a change of the state of the connection to a peer
+type Transition struct {
+	From PeerState
+	To   PeerState
+	Time time.Time
+}
+
+// connManager dials peers and tracks the state of every connection with
+// grpc.ClientConn.WaitForStateChange instead of polling it
+type connManager struct {
+	ps *PeerService
+
+	lock sync.Mutex
+	// watched connections
+	conns map[*grpc.ClientConn]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newConnManager(ps *PeerService) *connManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &connManager{
+		ps:     ps,
+		conns:  make(map[*grpc.ClientConn]struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// connect returns the connection to the peer. The existing connection is reused
+// unless it was shut down, otherwise a new connection is dialed and watched
+func (m *connManager) connect(p *Peer) (*grpc.ClientConn, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// the connection is read again from the peerstore as p may be outdated
+	conn, err := m.ps.peerstore.GetPeerConnection(p.Addr())
+	if err != nil {
+		return nil, err
+	}
+	if conn != nil && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+
+	opts := append([]grpc.DialOption{}, m.ps.dialOpts...)
+	opts = append(opts, m.seenInterceptors(p.Addr())...)
+	conn, err = grpc.Dial(p.Addr(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.ps.peerstore.SetPeerConnection(p.Addr(), conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Connect()
+
+	m.conns[conn] = struct{}{}
+	m.wg.Add(1)
+	go m.watch(p.Addr(), conn)
+
+	return conn, nil
+}
+
+// watch records the state transitions of the connection until it is shut down
+func (m *connManager) watch(addr string, conn *grpc.ClientConn) {
+	defer m.wg.Done()
+	defer func() {
+		m.lock.Lock()
+		delete(m.conns, conn)
+		m.lock.Unlock()
+	}()
+
+	state := connectivity.Idle
+	for {
+		current := conn.GetState()
+		if current != state {
+			t := Transition{
+				From: PeerStateFromString(state.String()),
+				To:   PeerStateFromString(current.String()),
+				Time: m.ps.clock.Now(),
+			}
+			m.ps.peerstore.RecordTransition(addr, t)
+			if t.To == Ready {
+				m.ps.peerstore.MarkSeen(addr)
+			}
+			m.ps.emitConnectivity(addr, t.To, t.Time)
+			state = current
+		}
+		if state == connectivity.Shutdown {
+			return
+		}
+		if !conn.WaitForStateChange(m.ctx, state) {
+			return
+		}
+	}
+}
+
+// seenInterceptors mark the peer at addr as seen on every successful rpc sent on its connection
+func (m *connManager) seenInterceptors(addr string) []grpc.DialOption {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			m.ps.peerstore.MarkSeen(addr)
+		}
+		return err
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err == nil {
+			m.ps.peerstore.MarkSeen(addr)
+		}
+		return s, err
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary),
+		grpc.WithChainStreamInterceptor(stream),
+	}
+}
+
+// waitReady blocks until the connection is ready, shut down or ctx is done
+func (m *connManager) waitReady(ctx context.Context, conn *grpc.ClientConn) error {
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return ErrConnectionShutdown
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// close stops watching the connections
+func (m *connManager) close() {
+	m.cancel()
+	m.wg.Wait()
+}
@@ -0,0 +1,203 @@
+package peer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultEventBuffer is the number of events buffered per subscriber.
+// Events are dropped for subscribers whose buffer is full
+const defaultEventBuffer = 64
+
+type EventType int
+
+const (
+	// PeerAdded is emitted when a peer is added to the peerstore
+	PeerAdded EventType = iota
+	// PeerUpdated is emitted when the attributes or record of a peer are updated
+	PeerUpdated
+	// PeerRemoved is emitted when a peer is removed or evicted from the peerstore
+	PeerRemoved
+	// ConnectivityChanged is emitted when the state of the connection to a peer changes
+	ConnectivityChanged
+)
+
+// String returns the string representation of the EventType
+func (t EventType) String() string {
+	switch t {
+	case PeerAdded:
+		return "PEER_ADDED"
+	case PeerUpdated:
+		return "PEER_UPDATED"
+	case PeerRemoved:
+		return "PEER_REMOVED"
+	case ConnectivityChanged:
+		return "CONNECTIVITY_CHANGED"
+	default:
+		return "INVALID_EVENT"
+	}
+}
+
+// Event is a change of the peerstore
+type Event struct {
+	Type EventType
+	Peer *Peer
+	// State is the connection state of the peer when the event was emitted
+	State PeerState
+	Time  time.Time
+	// Version is the version of the peerstore changes after the event. Subscribers
+	// without filter missed events, e.g. dropped for a full buffer, if the versions
+	// they receive are not contiguous. Connectivity changes are not recorded as
+	// peerstore changes and carry the current version
+	Version uint64
+}
+
+// EventFilter selects the events delivered to a subscriber
+type EventFilter struct {
+	// Types of the events to deliver. All types are delivered if empty
+	Types []EventType
+	// Attributes the peer of the event must have as in Peer.HasAttributes
+	Attributes PeerAttribute
+}
+
+func (f EventFilter) match(e Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return e.Peer.HasAttributes(f.Attributes)
+}
+
+type subscription struct {
+	filter EventFilter
+	ch     chan Event
+}
+
+// eventBus fans out events to subscribers without ever blocking the publisher
+type eventBus struct {
+	lock sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subs: make(map[*subscription]struct{}),
+	}
+}
+
+func (b *eventBus) subscribe(ctx context.Context, filter EventFilter) <-chan Event {
+	sub := &subscription{
+		filter: filter,
+		ch:     make(chan Event, defaultEventBuffer),
+	}
+
+	b.lock.Lock()
+	b.subs[sub] = struct{}{}
+	b.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.lock.Lock()
+		delete(b.subs, sub)
+		close(sub.ch)
+		b.lock.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// publish delivers the event to the matching subscribers and returns the number of
+// subscribers the event was dropped for because their buffer was full
+func (b *eventBus) publish(e Event) int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	dropped := 0
+	for sub := range b.subs {
+		if !sub.filter.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Subscribe returns a channel receiving the peer events matching the filter.
+// The channel is closed when ctx is done. Events are buffered and dropped if the
+// subscriber does not keep up, so slow subscribers never block the peer service
+func (ps *PeerService) Subscribe(ctx context.Context, filter EventFilter) <-chan Event {
+	return ps.events.subscribe(ctx, filter)
+}
+
+// SubscribeFunc calls fn for each peer event matching the filter until ctx is done.
+// fn is called sequentially from a dedicated goroutine
+func (ps *PeerService) SubscribeFunc(ctx context.Context, filter EventFilter, fn func(Event)) {
+	ch := ps.Subscribe(ctx, filter)
+	go func() {
+		for e := range ch {
+			fn(e)
+		}
+	}()
+}
+
+func (ps *PeerService) emit(t EventType, p *Peer) {
+	ps.publish(Event{
+		Type:  t,
+		Peer:  p,
+		State: p.GetState(),
+		Time:  ps.clock.Now(),
+	})
+}
+
+// emitConnectivity emits a ConnectivityChanged event for the peer entering state
+func (ps *PeerService) emitConnectivity(addr string, state PeerState, at time.Time) {
+	p, err := ps.peerstore.GetPeer(addr)
+	if err != nil {
+		return
+	}
+	ps.publish(Event{
+		Type:  ConnectivityChanged,
+		Peer:  p,
+		State: state,
+		Time:  at,
+	})
+}
+
+func (ps *PeerService) publish(e Event) {
+	// connectivity changes would make every incremental exchange resend the peer
+	if e.Type == ConnectivityChanged {
+		_, e.Version = ps.changes.current()
+	} else {
+		e.Version = ps.changes.record(e.Peer.Addr(), e.Type == PeerRemoved)
+	}
+	if e.Type == PeerRemoved {
+		ps.views.remove(e.Peer.Addr())
+		ps.membership.forget(e.Peer.Addr())
+	}
+	if dropped := ps.events.publish(e); dropped > 0 {
+		ps.logger.Debug().Str("peer", e.Peer.Addr()).Str("event", e.Type.String()).Int("subscribers", dropped).Msg("dropped peer event")
+	}
+}
+
+// emitPeer emits an event for the peer as currently stored in the peerstore
+func (ps *PeerService) emitPeer(t EventType, addr string) {
+	p, err := ps.peerstore.GetPeer(addr)
+	if err != nil {
+		return
+	}
+	ps.emit(t, p)
+}
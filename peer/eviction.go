@@ -0,0 +1,197 @@
+package peer
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Liveness tracks the reachability of a peer
+type Liveness struct {
+	// LastSeen is the last time the peer answered or contacted the local node.
+	// It is initialized to the time the peer was added to the peerstore
+	LastSeen time.Time
+	// Failures is the number of consecutive failed attempts to reach the peer
+	Failures int
+}
+
+// EvictionPolicy decides when a peer is evicted from the peerstore
+type EvictionPolicy struct {
+	// MaxFailures evicts peers after this many consecutive failures
+	MaxFailures int
+	// MaxAge evicts peers not seen for this long
+	MaxAge time.Duration
+	// TombstoneTTL is the time an evicted peer cannot be re-added through AddPeers
+	TombstoneTTL time.Duration
+}
+
+// MarkSeen records a successful contact with the peer and resets its failures
+func (ps *PeerStore) MarkSeen(addr string) error {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return ErrInvalidPeerAddress
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	l, ok := ps.liveness[addr]
+	if !ok {
+		return ErrPeerNotFouund
+	}
+	l.LastSeen = ps.clock.Now()
+	l.Failures = 0
+	return nil
+}
+
+// MarkFailed records a failed attempt to reach the peer and returns the number of consecutive failures
+func (ps *PeerStore) MarkFailed(addr string) (int, error) {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return 0, ErrInvalidPeerAddress
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	l, ok := ps.liveness[addr]
+	if !ok {
+		return 0, ErrPeerNotFouund
+	}
+	l.Failures++
+	return l.Failures, nil
+}
+
+// GetLiveness returns the liveness of the peer
+func (ps *PeerStore) GetLiveness(addr string) (Liveness, error) {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return Liveness{}, ErrInvalidPeerAddress
+	}
+
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	l, ok := ps.liveness[addr]
+	if !ok {
+		return Liveness{}, ErrPeerNotFouund
+	}
+	return *l, nil
+}
+
+// Evict removes the peers violating the policy, closes their connections and
+// tombstones their addresses. It returns the evicted peers
+func (ps *PeerStore) Evict(policy EvictionPolicy) []*Peer {
+	var evicted []*Peer
+	var conns []*grpc.ClientConn
+
+	ps.lock.Lock()
+	now := ps.clock.Now()
+	for addr, l := range ps.liveness {
+		failed := policy.MaxFailures > 0 && l.Failures >= policy.MaxFailures
+		expired := policy.MaxAge > 0 && now.Sub(l.LastSeen) >= policy.MaxAge
+		if !failed && !expired {
+			continue
+		}
+
+		p := &Peer{PeerInfo: newPeerInfo(addr, ps.peers[addr])}
+		evicted = append(evicted, p)
+		conns = append(conns, ps.conns[addr])
+		ps.evictLocked(addr, now.Add(policy.TombstoneTTL))
+	}
+	ps.lock.Unlock()
+
+	closeConns(conns)
+	return evicted
+}
+
+// EvictPeer removes the peer, closes its connection and tombstones its address for ttl
+func (ps *PeerStore) EvictPeer(addr string, ttl time.Duration) error {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return ErrInvalidPeerAddress
+	}
+
+	ps.lock.Lock()
+	if _, ok := ps.peers[addr]; !ok {
+		ps.lock.Unlock()
+		return ErrPeerNotFouund
+	}
+	conn := ps.conns[addr]
+	ps.evictLocked(addr, ps.clock.Now().Add(ttl))
+	ps.lock.Unlock()
+
+	closeConns([]*grpc.ClientConn{conn})
+	return nil
+}
+
+// Tombstoned reports whether the address was evicted and its tombstone has not expired yet
+func (ps *PeerStore) Tombstoned(addr string) bool {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return false
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	expiry, ok := ps.tombstones[addr]
+	if !ok {
+		return false
+	}
+	if ps.clock.Now().After(expiry) {
+		delete(ps.tombstones, addr)
+		return false
+	}
+	return true
+}
+
+// ClearTombstone removes the tombstone of the address so that the peer can be added again
+func (ps *PeerStore) ClearTombstone(addr string) error {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return ErrInvalidPeerAddress
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.tombstones, addr)
+	return nil
+}
+
+// unexpiredTombstones returns the tombstones which did not expire mapped to their expiry
+func (ps *PeerStore) unexpiredTombstones() map[string]time.Time {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	now := ps.clock.Now()
+	tombstones := make(map[string]time.Time, len(ps.tombstones))
+	for addr, expiry := range ps.tombstones {
+		if !now.After(expiry) {
+			tombstones[addr] = expiry
+		}
+	}
+	return tombstones
+}
+
+func (ps *PeerStore) evictLocked(addr string, expiry time.Time) {
+	ps.deleteLocked(addr)
+	ps.tombstones[addr] = expiry
+
+	// drop expired tombstones
+	now := ps.clock.Now()
+	for a, e := range ps.tombstones {
+		if now.After(e) {
+			delete(ps.tombstones, a)
+		}
+	}
+}
+
+func closeConns(conns []*grpc.ClientConn) {
+	for _, conn := range conns {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package peer
+
+import (
+	"context"
+	"sync"
+
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc"
+)
+
+// maxExchangePages bounds the number of requests of a single peer exchange
+const maxExchangePages = 64
+
+// neighborView is the peers of a neighbor as received through peer exchanges
+type neighborView struct {
+	epoch   string
+	version uint64
+	// states are the connection states of the peers of the neighbor by address
+	states map[string]PeerState
+}
+
+// neighborViews keeps the view of every neighbor so that only the changes since the
+// last exchange are requested
+type neighborViews struct {
+	lock  sync.Mutex
+	views map[string]*neighborView
+}
+
+func newNeighborViews() *neighborViews {
+	return &neighborViews{views: make(map[string]*neighborView)}
+}
+
+// version returns the version of the peers of the neighbor last received
+func (v *neighborViews) version(addr string) (string, uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	view, ok := v.views[addr]
+	if !ok {
+		return "", 0
+	}
+	return view.epoch, view.version
+}
+
+// reset replaces the view of the neighbor with a full snapshot
+func (v *neighborViews) reset(addr, epoch string, version uint64, states map[string]PeerState) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.views[addr] = &neighborView{epoch: epoch, version: version, states: states}
+}
+
+// apply applies the changes received from the neighbor to its view
+func (v *neighborViews) apply(addr string, version uint64, updated []*p2p_pb.Peer, removed []string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	view, ok := v.views[addr]
+	if !ok {
+		return
+	}
+	view.version = version
+	for _, p := range updated {
+		view.states[p.Address] = PeerStateFromString(p.State)
+	}
+	for _, a := range removed {
+		delete(view.states, a)
+	}
+}
+
+// connected returns the addresses of the peers the neighbor is connected to
+func (v *neighborViews) connected(addr string) []string {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	view, ok := v.views[addr]
+	if !ok {
+		return nil
+	}
+	var addrs []string
+	for a, state := range view.states {
+		if state == Ready {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+func (v *neighborViews) remove(addr string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	delete(v.views, addr)
+}
+
+// exchangePeers requests the changes of the peers of the neighbor at addr since the
+// last exchange, or a full snapshot if the neighbor does not know the last version,
+// and returns the peers added or updated. The neighbor itself is returned if it
+// advertised a valid record
+func (ps *PeerService) exchangePeers(ctx context.Context, conn *grpc.ClientConn, addr string) ([]*Peer, error) {
+	epoch, version := ps.views.version(addr)
+	req := &p2p_pb.GetPeersRequest{
+		Self:    RecordToPb(ps.Self().Record),
+		Epoch:   epoch,
+		Version: version,
+	}
+
+	var peers []*Peer
+	var snapshot map[string]PeerState
+	var snapshotVersion uint64
+	for page := 0; page < maxExchangePages; page++ {
+		res, err := ps.client.exchange(ctx, conn, req)
+		if err != nil {
+			return nil, err
+		}
+		if page == 0 {
+			if r := RecordFromPb(res.Self); r != nil && r.Verify() == nil {
+				peers = append(peers, NewPeerFromRecord(r))
+			}
+		}
+		peers = append(peers, peersFromPbPeers(res.Peers)...)
+
+		// neighbors without versions always return the full list of their peers
+		if res.Full || res.Epoch == "" {
+			if snapshot == nil {
+				snapshot = make(map[string]PeerState)
+				snapshotVersion = res.Version
+			}
+			for _, p := range res.Peers {
+				snapshot[p.Address] = PeerStateFromString(p.State)
+			}
+			if res.Cursor != "" {
+				req.Cursor = res.Cursor
+				continue
+			}
+			// the changes made while the pages were requested follow the version of the first page
+			ps.views.reset(addr, res.Epoch, snapshotVersion, snapshot)
+			break
+		}
+
+		ps.views.apply(addr, res.Version, res.Peers, res.Removed)
+		if !res.More {
+			break
+		}
+		req.Version = res.Version
+	}
+	return peers, nil
+}
@@ -0,0 +1,211 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// snapshotPage is the number of peers of a snapshot page of the test neighbor
+const snapshotPage = 100
+
+// testNeighbor serves the peer exchange like the rpc service from a change log. Every
+// change is sent in its own response so that each one takes a request
+type testNeighbor struct {
+	p2p_pb.UnimplementedPeerServiceServer
+
+	lock     sync.Mutex
+	log      *changeLog
+	peers    map[string]bool
+	requests int
+}
+
+func newTestNeighbor() *testNeighbor {
+	return &testNeighbor{log: newChangeLog(), peers: make(map[string]bool)}
+}
+
+// change adds or updates the peers at addrs
+func (n *testNeighbor) change(addrs ...string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, addr := range addrs {
+		n.peers[addr] = true
+		n.log.record(addr, false)
+	}
+}
+
+func (n *testNeighbor) GetPeers(ctx context.Context, req *p2p_pb.GetPeersRequest) (*p2p_pb.GetPeersResponse, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.requests++
+	epoch, version := n.log.current()
+	res := &p2p_pb.GetPeersResponse{Epoch: epoch, Version: version}
+
+	if req.Epoch == epoch && req.Cursor == "" {
+		if changes, ok := n.log.since(req.Version); ok {
+			if len(changes) > 0 {
+				res.Peers = []*p2p_pb.Peer{{Address: changes[0].Addr, State: Ready.String()}}
+				res.Version = changes[0].Version
+				res.More = len(changes) > 1
+			}
+			return res, nil
+		}
+	}
+
+	res.Full = true
+	var addrs []string
+	for addr := range n.peers {
+		if addr > req.Cursor {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	for i, addr := range addrs {
+		if i == snapshotPage {
+			res.Cursor = addrs[i-1]
+			break
+		}
+		res.Peers = append(res.Peers, &p2p_pb.Peer{Address: addr, State: Ready.String()})
+	}
+	return res, nil
+}
+
+// takeRequests returns the number of requests served since the last call
+func (n *testNeighbor) takeRequests() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	requests := n.requests
+	n.requests = 0
+	return requests
+}
+
+func (n *testNeighbor) current() (string, uint64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.log.current()
+}
+
+func serveNeighbor(t *testing.T, n *testNeighbor) *grpc.ClientConn {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	p2p_pb.RegisterPeerServiceServer(srv, n)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newTestPeerService(t *testing.T) *PeerService {
+	t.Helper()
+
+	ps, err := NewPeerService(&config.Config{
+		Local: config.Peer{Addr: "127.0.0.1:1", Name: "self", ClusterName: "c"},
+	}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+func testAddrs(from, n int) []string {
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("10.0.%d.%d:9000", (from+i)/256, (from+i)%256)
+	}
+	return addrs
+}
+
+func TestExchangeDeltasAndSnapshotFallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n := newTestNeighbor()
+	conn := serveNeighbor(t, n)
+	ps := newTestPeerService(t)
+	const neighbor = "127.0.0.1:2"
+
+	exchange := func() {
+		t.Helper()
+		if _, err := ps.exchangePeers(ctx, conn, neighbor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectView := func(requests int) {
+		t.Helper()
+		if got := n.takeRequests(); got != requests {
+			t.Errorf("exchange sent %d requests, want %d", got, requests)
+		}
+		epoch, version := n.current()
+		if e, v := ps.views.version(neighbor); e != epoch || v != version {
+			t.Errorf("view at %s/%d, want %s/%d", e, v, epoch, version)
+		}
+		if got := len(ps.views.connected(neighbor)); got != len(n.peers) {
+			t.Errorf("view has %d peers, want %d", got, len(n.peers))
+		}
+	}
+
+	// the first exchange gets a snapshot in pages
+	n.change(testAddrs(0, 2*snapshotPage+1)...)
+	exchange()
+	expectView(3)
+
+	// the next exchanges only get the latest change of every changed peer
+	n.change(testAddrs(0, 1)...)
+	n.change(testAddrs(0, 2)...)
+	exchange()
+	expectView(2)
+
+	// a single exchange stops after maxExchangePages and resumes from there
+	n.change(testAddrs(2*snapshotPage+1, maxExchangePages+10)...)
+	exchange()
+	if got := n.takeRequests(); got != maxExchangePages {
+		t.Errorf("exchange sent %d requests, want %d", got, maxExchangePages)
+	}
+	exchange()
+	expectView(10)
+
+	// once the changes since the view are truncated the neighbor sends a snapshot
+	n.change(testAddrs(0, maxChanges+1)...)
+	exchange()
+	expectView((len(n.peers) + snapshotPage - 1) / snapshotPage)
+}
+
+func TestConnectivityChangesAreNotRecorded(t *testing.T) {
+	ps := newTestPeerService(t)
+	if err := ps.AddPeer(NewPeer("127.0.0.1:2", nil)); err != nil {
+		t.Fatal(err)
+	}
+	_, version := ps.Version()
+
+	ps.emitConnectivity("127.0.0.1:2", Ready, time.Now())
+	if _, v := ps.Version(); v != version {
+		t.Errorf("connectivity change bumped the version from %d to %d", version, v)
+	}
+	if changes, _ := ps.Changes(version); len(changes) != 0 {
+		t.Errorf("connectivity change recorded: %v", changes)
+	}
+}
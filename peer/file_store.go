@@ -0,0 +1,343 @@
+package peer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"google.golang.org/grpc"
+)
+
+// FileStore is a Store persisting the peers, their attributes, records and failures
+// and the tombstones of the evicted addresses in an append-only journal. The journal
+// is replayed when the store is opened and compacted once it grows too large. Every method delegates explicitly to the
+// in-memory store so that no mutation is left out of the journal
+type FileStore struct {
+	store *PeerStore
+
+	// lock serializes the mutations with their journal entries
+	lock    sync.Mutex
+	journal *journal
+}
+
+// NewFileStore opens the store persisted in dir and loads the peers and the tombstones
+// it contains. The peers are considered seen when loaded so that they are not evicted
+// for the time the node was down before they could be contacted again
+func NewFileStore(dir string) (*FileStore, error) {
+	return newFileStore(dir, clock.Real)
+}
+
+// newFileStore opens the store persisted in dir with a clock timestamping liveness
+// and tombstones from the time the store is loaded
+func newFileStore(dir string, c clock.Clock) (*FileStore, error) {
+	now := c.Now()
+	j, state, err := openJournal(dir, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := NewPeerStore()
+	ps.clock = c
+	for addr, e := range state.peers {
+		ps.peers[addr] = &PeerInfo{
+			Addr:        addr,
+			Attributes:  e.Attributes,
+			ClusterName: e.ClusterName,
+			Name:        e.Name,
+			ID:          e.ID,
+			Record:      e.Record,
+		}
+		ps.indexLocked(ps.peers[addr])
+		ps.liveness[addr] = &Liveness{
+			LastSeen: now,
+			Failures: e.Failures,
+		}
+	}
+	for addr, expiry := range state.tombstones {
+		ps.tombstones[addr] = expiry
+	}
+
+	return &FileStore{
+		store:   ps,
+		journal: j,
+	}, nil
+}
+
+func (fs *FileStore) Exists(addr string) (bool, error) {
+	return fs.store.Exists(addr)
+}
+
+func (fs *FileStore) AddPeer(p *Peer) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	prev := fs.previousAddr(p)
+	if err := fs.store.AddPeer(p); err != nil {
+		return err
+	}
+	return fs.persistMoved(p.Addr(), prev)
+}
+
+func (fs *FileStore) AddPeers(peers []*Peer, skip_errors bool) ([]*Peer, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	prevs := make(map[*Peer]string, len(peers))
+	for _, p := range peers {
+		prevs[p] = fs.previousAddr(p)
+	}
+
+	added, updated, err := fs.store.addPeers(peers, skip_errors)
+
+	// only the peers which were added, moved or updated are journaled
+	for _, p := range append(append([]*Peer{}, added...), updated...) {
+		if perr := fs.persistMoved(p.Addr(), prevs[p]); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return added, err
+}
+
+func (fs *FileStore) UpdatePeer(p *Peer) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	prev := fs.previousAddr(p)
+	if err := fs.store.UpdatePeer(p); err != nil {
+		return err
+	}
+	return fs.persistMoved(p.Addr(), prev)
+}
+
+func (fs *FileStore) PutRecord(r *Record) (bool, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	prev := fs.previousAddr(NewPeerFromRecord(r))
+	added, err := fs.store.PutRecord(r)
+	if err != nil {
+		return added, err
+	}
+	return added, fs.persistMoved(r.Addrs[0], prev)
+}
+
+func (fs *FileStore) RemovePeer(p *Peer) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.store.RemovePeer(p); err != nil {
+		return err
+	}
+	return fs.persist(p.Addr())
+}
+
+// MarkSeen records a successful contact with the peer. Only resetting failures is
+// journaled as the peers are considered seen when the store is opened anyway
+func (fs *FileStore) MarkSeen(addr string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	l, err := fs.store.GetLiveness(addr)
+	if err != nil {
+		return err
+	}
+	if err := fs.store.MarkSeen(addr); err != nil {
+		return err
+	}
+	if l.Failures == 0 {
+		return nil
+	}
+	return fs.persist(addr)
+}
+
+func (fs *FileStore) MarkFailed(addr string) (int, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	failures, err := fs.store.MarkFailed(addr)
+	if err != nil {
+		return failures, err
+	}
+	return failures, fs.persist(addr)
+}
+
+func (fs *FileStore) Evict(policy EvictionPolicy) []*Peer {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	evicted := fs.store.Evict(policy)
+	for _, p := range evicted {
+		fs.persistEvicted(p.Addr())
+	}
+	return evicted
+}
+
+func (fs *FileStore) EvictPeer(addr string, ttl time.Duration) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.store.EvictPeer(addr, ttl); err != nil {
+		return err
+	}
+	return fs.persistEvicted(addr)
+}
+
+func (fs *FileStore) GetPeer(addr string) (*Peer, error) {
+	return fs.store.GetPeer(addr)
+}
+
+func (fs *FileStore) GetPeerByID(id string) (*Peer, error) {
+	return fs.store.GetPeerByID(id)
+}
+
+func (fs *FileStore) GetPeerByName(cluster, name string) (*Peer, error) {
+	return fs.store.GetPeerByName(cluster, name)
+}
+
+func (fs *FileStore) GetPeers() []*Peer {
+	return fs.store.GetPeers()
+}
+
+func (fs *FileStore) GetPeersWithAttributes(attrs map[string]string) []*Peer {
+	return fs.store.GetPeersWithAttributes(attrs)
+}
+
+// GetPeerConnection returns the connection to the peer. Connections are not persisted
+func (fs *FileStore) GetPeerConnection(addr string) (*grpc.ClientConn, error) {
+	return fs.store.GetPeerConnection(addr)
+}
+
+// SetPeerConnection sets the connection to the peer. Connections are not persisted
+func (fs *FileStore) SetPeerConnection(addr string, conn *grpc.ClientConn) (*grpc.ClientConn, error) {
+	return fs.store.SetPeerConnection(addr, conn)
+}
+
+func (fs *FileStore) GetLiveness(addr string) (Liveness, error) {
+	return fs.store.GetLiveness(addr)
+}
+
+func (fs *FileStore) Tombstoned(addr string) bool {
+	return fs.store.Tombstoned(addr)
+}
+
+func (fs *FileStore) ClearTombstone(addr string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.store.ClearTombstone(addr); err != nil {
+		return err
+	}
+	addr, _ = validatePeerAddr(addr)
+	if err := fs.journal.append(&journalEntry{Op: opClearTombstone, Addr: addr}); err != nil {
+		return err
+	}
+	return fs.compact()
+}
+
+// RecordTransition records a connection state transition. Transitions are not persisted
+func (fs *FileStore) RecordTransition(addr string, t Transition) error {
+	return fs.store.RecordTransition(addr, t)
+}
+
+func (fs *FileStore) GetTransitions(addr string) ([]Transition, error) {
+	return fs.store.GetTransitions(addr)
+}
+
+// Close closes the journal
+func (fs *FileStore) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return fs.journal.close()
+}
+
+// previousAddr returns the address the peer of the signed record is stored at
+func (fs *FileStore) previousAddr(p *Peer) string {
+	if p.Record == nil {
+		return ""
+	}
+	stored, err := fs.store.GetPeerByID(p.Record.ID)
+	if err != nil {
+		return ""
+	}
+	return stored.Addr()
+}
+
+// persistMoved persists the peer and its previous address if the peer moved
+func (fs *FileStore) persistMoved(addr, prev string) error {
+	if err := fs.persist(addr); err != nil {
+		return err
+	}
+	if prev == "" || prev == addr {
+		return nil
+	}
+	return fs.persist(prev)
+}
+
+// persist appends the current state of the peer to the journal and compacts
+// the journal once it grew too large. A missing peer is journaled as deleted
+func (fs *FileStore) persist(addr string) error {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return ErrInvalidPeerAddress
+	}
+
+	e := &journalEntry{Op: opDelete, Addr: addr}
+	if p, err := fs.store.GetPeer(addr); err == nil {
+		e = fs.journalEntry(p)
+	}
+	if err := fs.journal.append(e); err != nil {
+		return err
+	}
+	return fs.compact()
+}
+
+// persistEvicted journals the removal of an evicted peer and the tombstone of its address
+func (fs *FileStore) persistEvicted(addr string) error {
+	if err := fs.persist(addr); err != nil {
+		return err
+	}
+	addr, _ = validatePeerAddr(addr)
+	expiry, ok := fs.store.unexpiredTombstones()[addr]
+	if !ok {
+		return nil
+	}
+	if err := fs.journal.append(&journalEntry{Op: opTombstone, Addr: addr, Expires: expiry}); err != nil {
+		return err
+	}
+	return fs.compact()
+}
+
+// compact rewrites the journal as a snapshot of the peers and the tombstones once
+// it grew too large
+func (fs *FileStore) compact() error {
+	tombstones := fs.store.unexpiredTombstones()
+	if !fs.journal.shouldCompact(fs.store.count() + len(tombstones)) {
+		return nil
+	}
+	peers := fs.store.GetPeers()
+	state := &journalState{
+		peers:      make(map[string]*journalEntry, len(peers)),
+		tombstones: tombstones,
+	}
+	for _, p := range peers {
+		state.peers[p.Addr()] = fs.journalEntry(p)
+	}
+	return fs.journal.compact(state)
+}
+
+func (fs *FileStore) journalEntry(p *Peer) *journalEntry {
+	e := &journalEntry{
+		Op:          opPut,
+		Addr:        p.Addr(),
+		Attributes:  p.Attributes(),
+		ClusterName: p.ClusterName,
+		Name:        p.Name,
+		ID:          p.ID,
+		Record:      p.Record,
+	}
+	if l, err := fs.store.GetLiveness(p.Addr()); err == nil {
+		e.Failures = l.Failures
+	}
+	return e
+}
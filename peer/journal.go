@@ -0,0 +1,192 @@
+package peer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	journalFile = "peerstore.log"
+
+	// minCompactEntries is the minimum number of journal entries before compaction
+	minCompactEntries = 1024
+)
+
+const (
+	opPut            = "put"
+	opDelete         = "delete"
+	opTombstone      = "tombstone"
+	opClearTombstone = "clearTombstone"
+)
+
+// journalEntry is a line of the journal. A put entry contains the full state of a peer
+// but its last contact, as the peers are considered seen when the journal is loaded.
+// A tombstone entry contains the expiry of the tombstone of an evicted address
+type journalEntry struct {
+	Op          string            `json:"op"`
+	Addr        string            `json:"addr"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	ClusterName string            `json:"clusterName,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	ID          string            `json:"id,omitempty"`
+	Record      *Record           `json:"record,omitempty"`
+	Failures    int               `json:"failures,omitempty"`
+	Expires     time.Time         `json:"expires,omitempty"`
+}
+
+// journalState is the state replayed from the journal
+type journalState struct {
+	peers map[string]*journalEntry
+	// tombstones are the tombstoned addresses mapped to the expiry of their tombstone
+	tombstones map[string]time.Time
+}
+
+// journal is an append-only log of the peerstore changes. It is replayed on load
+// and compacted into a snapshot of the live peers once it grows too large
+type journal struct {
+	path    string
+	file    *os.File
+	entries int
+}
+
+// openJournal opens the journal in dir and returns the peers and the tombstones it
+// contains. The tombstones expired at now are dropped
+func openJournal(dir string, now time.Time) (*journal, *journalState, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+
+	j := &journal{path: filepath.Join(dir, journalFile)}
+	state, err := j.replay()
+	if err != nil {
+		return nil, nil, err
+	}
+	for addr, expiry := range state.tombstones {
+		if now.After(expiry) {
+			delete(state.tombstones, addr)
+		}
+	}
+
+	// compact on open so that the journal starts from a snapshot
+	if err := j.compact(state); err != nil {
+		return nil, nil, err
+	}
+	return j, state, nil
+}
+
+// replay reads the journal and returns the latest state of every peer and tombstone
+func (j *journal) replay() (*journalState, error) {
+	state := &journalState{
+		peers:      make(map[string]*journalEntry),
+		tombstones: make(map[string]time.Time),
+	}
+
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := &journalEntry{}
+		// a partially written last line is ignored
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			continue
+		}
+		switch e.Op {
+		case opPut:
+			// a peer is only added once the tombstone of its address expired
+			state.peers[e.Addr] = e
+			delete(state.tombstones, e.Addr)
+		case opDelete:
+			delete(state.peers, e.Addr)
+		case opTombstone:
+			state.tombstones[e.Addr] = e.Expires
+		case opClearTombstone:
+			delete(state.tombstones, e.Addr)
+		}
+	}
+	return state, scanner.Err()
+}
+
+func (j *journal) append(e *journalEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	j.entries++
+	return nil
+}
+
+// shouldCompact returns true if the journal holds much more entries than live peers and tombstones
+func (j *journal) shouldCompact(live int) bool {
+	return j.entries >= minCompactEntries && j.entries >= 4*live
+}
+
+// compact rewrites the journal as a snapshot of the given peers and tombstones
+func (j *journal) compact(state *journalState) error {
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	entries := make([]*journalEntry, 0, len(state.peers)+len(state.tombstones))
+	for _, e := range state.peers {
+		entries = append(entries, e)
+	}
+	for addr, expiry := range state.tombstones {
+		entries = append(entries, &journalEntry{Op: opTombstone, Addr: addr, Expires: expiry})
+	}
+	for _, e := range entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	j.entries = len(entries)
+	return nil
+}
+
+func (j *journal) close() error {
+	if j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
@@ -69,6 +69,16 @@ func PeerStateFromString(s string) PeerState {
 type PeerInfo struct {
 	Addr       string
 	Attributes map[string]string
+	// ClusterName is the cluster the peer is a member of. It is empty if unknown
+	ClusterName string
+	// Name is the unique name of the peer. It is empty if unknown
+	Name string
+
+	// ID is the peer ID derived from the peer's public key. It is empty until
+	// a signed record of the peer is received
+	ID string
+	// Record is the latest signed record of the peer
+	Record *Record
 }
 
 // Peer contains the peer information and the connection
@@ -88,6 +98,22 @@ func NewPeer(addr string, attrs map[string]string) *Peer {
 	}
 }
 
+// NewPeerFromRecord creates a new peer advertised by the given record.
+// The first address of the record is used as the address of the peer
+func NewPeerFromRecord(r *Record) *Peer {
+	return &Peer{
+		PeerInfo: &PeerInfo{
+			Addr:        r.Addrs[0],
+			Attributes:  r.Attributes,
+			ClusterName: r.ClusterName,
+			Name:        r.Name,
+			ID:          r.ID,
+			Record:      r,
+		},
+		conn: nil,
+	}
+}
+
 // Addr returns the address of the peer
 func (p *Peer) Addr() string {
 	return p.PeerInfo.Addr
@@ -98,6 +124,11 @@ func (p *Peer) Attributes() map[string]string {
 	return p.PeerInfo.Attributes
 }
 
+// InCluster returns true if the peer is a member of the given cluster
+func (p *Peer) InCluster(name string) bool {
+	return p.PeerInfo.ClusterName == name
+}
+
 func (p *Peer) HasAttributes(opts PeerAttribute) bool {
 	attrs := p.Attributes()
 	for k, v := range opts {
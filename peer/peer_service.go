@@ -3,57 +3,545 @@ package peer
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/mr-shifu/grpc-p2p/clock"
 	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/identity"
+	"github.com/mr-shifu/grpc-p2p/transport"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
-	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// StoreMemory keeps the peerstore in memory
+	StoreMemory = "memory"
+	// StoreFile persists the peerstore in a data directory
+	StoreFile = "file"
+)
+
+// identityFile is the key file of the node in the data directory
+const identityFile = "identity.key"
+
+const (
+	defaultMaxFailures  = 5
+	defaultMaxAge       = 5 * time.Minute
+	defaultTombstoneTTL = 1 * time.Minute
 )
 
 type PeerService struct {
+	// lock guards self and bootstrap which are replaced when the config is reloaded
+	lock      sync.RWMutex
 	self      *Peer
+	identity  *identity.Identity
 	bootstrap []config.Peer
-	peerstore *PeerStore
+	peerstore Store
 	client    *Client
 
+	// policy evicting unreachable peers from the peerstore
+	eviction EvictionPolicy
+
+	// dial options used to connect to peers
+	dialOpts []grpc.DialOption
+
+	// events notifies subscribers of the changes of the peerstore
+	events *eventBus
+
+	// changes numbers the changes of the peerstore for incremental peer exchanges
+	changes *changeLog
+	// views are the peers of every neighbor as received through peer exchanges
+	views *neighborViews
+
+	// conns dials peers and tracks the state of the connections
+	conns *connManager
+
+	// membership decides which peers are accepted based on their cluster
+	membership *membership
+
+	// routes are the links between peers used to reach peers which are not directly connected
+	routes *routingTable
+
+	// clock timestamps records, events and liveness
+	clock clock.Clock
+
 	logger zerolog.Logger
 }
 
-func NewPeerService(cfg *config.Config, logger zerolog.Logger) *PeerService {
-	store := NewPeerStore()
+// Option configures the peer service
+type Option func(*options)
+
+type options struct {
+	store    Store
+	dialOpts []grpc.DialOption
+	clock    clock.Clock
+}
+
+// WithStore makes the peer service use the given store instead of the store selected in the config
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithDialOptions appends dial options to the options used to connect to peers,
+// e.g. interceptors. The transport credentials of the config are applied first
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
 
-	self := NewPeer(cfg.Local.Addr, cfg.Local.Attributes)
+// WithClock makes the peer service and the peerstore it creates use the given clock
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func NewPeerService(cfg *config.Config, logger zerolog.Logger, opts ...Option) (*PeerService, error) {
+	o := &options{clock: clock.Real}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	creds, err := transport.DialOption(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := loadIdentity(cfg.Identity, cfg.Store)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Identity.KeyFile == "" && cfg.Store.Dir == "" {
+		logger.Warn().Str("id", id.ID()).Msg("no key file nor data directory, the peer ID changes on restart")
+	}
+
+	// the persisted peerstore is loaded before adding the bootstrap peers
+	store := o.store
+	if store == nil {
+		store, err = newStore(cfg.Store, o.clock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the address of self is normalized like the addresses of the peerstore
+	addr, err := validatePeerAddr(cfg.Local.Addr)
+	if err != nil {
+		return nil, err
+	}
+	record := &Record{
+		Addrs:       []string{addr},
+		Attributes:  cfg.Local.Attributes,
+		ClusterName: cfg.Local.ClusterName,
+		Name:        cfg.Local.Name,
+		Seq:         nextSeq(o.clock, 0),
+	}
+	// unnamed nodes are named after their ID so that every node has a unique name
+	if record.Name == "" {
+		record.Name = id.ID()
+	}
+	record.Sign(id)
+	self := NewPeerFromRecord(record)
 
 	ps := &PeerService{
-		self:      self,
-		bootstrap: cfg.Bootstrap,
-		peerstore: store,
-		client:    NewClient(),
-		logger:    logger,
+		self:       self,
+		identity:   id,
+		bootstrap:  cfg.Bootstrap,
+		peerstore:  store,
+		client:     NewClient(),
+		eviction:   evictionPolicy(cfg.Eviction),
+		dialOpts:   append([]grpc.DialOption{creds}, o.dialOpts...),
+		events:     newEventBus(),
+		changes:    newChangeLog(),
+		views:      newNeighborViews(),
+		membership: newMembership(cfg.Local, cfg.Cluster),
+		routes:     newRoutingTable(cfg.Routing.LinkTTL),
+		clock:      o.clock,
+		logger:     logger,
 	}
+	ps.conns = newConnManager(ps)
 
 	// add bootstrap nodes into peerstore
 	for _, peer := range cfg.Bootstrap {
-		if peer.Addr != ps.self.Addr() {
-			p := NewPeer(peer.Addr, peer.Attributes)
+		if ps.IsSelf(peer.Addr) {
+			continue
+		}
+		p := newBootstrapPeer(peer)
+		if ps.membership.admits(p) {
 			ps.peerstore.AddPeer(p)
 		}
 	}
 
-	return ps
+	return ps, nil
+}
+
+// NewStore creates the store selected in the config
+func NewStore(cfg config.Store) (Store, error) {
+	return newStore(cfg, clock.Real)
+}
+
+// newStore creates the store selected in the config with a clock timestamping
+// liveness and tombstones
+func newStore(cfg config.Store, c clock.Clock) (Store, error) {
+	typ := cfg.Type
+	if typ == "" && cfg.Dir != "" {
+		typ = StoreFile
+	}
+
+	switch typ {
+	case "", StoreMemory:
+		ps := NewPeerStore()
+		ps.setClock(c)
+		return ps, nil
+	case StoreFile:
+		if cfg.Dir == "" {
+			return nil, errors.New("peerstore: file store requires a data directory")
+		}
+		return newFileStore(cfg.Dir, c)
+	default:
+		return nil, fmt.Errorf("peerstore: unknown store type %q", cfg.Type)
+	}
+}
+
+// nextSeq returns the sequence number of a record superseding a record with seq.
+// Sequence numbers are derived from the clock so that records signed after a
+// restart supersede the records signed before
+func nextSeq(c clock.Clock, seq uint64) uint64 {
+	next := uint64(c.Now().UnixNano())
+	if next <= seq {
+		next = seq + 1
+	}
+	return next
+}
+
+func newBootstrapPeer(cfg config.Peer) *Peer {
+	p := NewPeer(cfg.Addr, cfg.Attributes)
+	p.ClusterName = cfg.ClusterName
+	p.Name = cfg.Name
+	return p
+}
+
+func evictionPolicy(cfg config.Eviction) EvictionPolicy {
+	policy := EvictionPolicy{
+		MaxFailures:  cfg.MaxFailures,
+		MaxAge:       cfg.MaxAge,
+		TombstoneTTL: cfg.TombstoneTTL,
+	}
+	if policy.MaxFailures <= 0 {
+		policy.MaxFailures = defaultMaxFailures
+	}
+	if policy.MaxAge <= 0 {
+		policy.MaxAge = defaultMaxAge
+	}
+	if policy.TombstoneTTL <= 0 {
+		policy.TombstoneTTL = defaultTombstoneTTL
+	}
+	return policy
+}
+
+// loadIdentity loads the key file or the key persisted in the data directory so that
+// the peer ID is stable across restarts. A key is generated if the file does not exist
+func loadIdentity(cfg config.Identity, store config.Store) (*identity.Identity, error) {
+	path := cfg.KeyFile
+	if path == "" && store.Dir != "" {
+		if err := os.MkdirAll(store.Dir, 0700); err != nil {
+			return nil, err
+		}
+		path = filepath.Join(store.Dir, identityFile)
+	}
+	if path == "" {
+		return identity.Generate()
+	}
+	return identity.FromFile(path)
 }
 
 func (ps *PeerService) Self() *Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
 	return ps.self
 }
 
+// IsSelf returns true if addr is the address of self once normalized
+func (ps *PeerService) IsSelf(addr string) bool {
+	addr, err := validatePeerAddr(addr)
+	return err == nil && addr == ps.Self().Addr()
+}
+
+// SetAttributes re-signs the record of self with the given attributes and a new
+// sequence number. Peers replace the previous record on the next exchange
+func (ps *PeerService) SetAttributes(attrs map[string]string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	old := ps.self.Record
+	r := &Record{
+		Addrs:       old.Addrs,
+		Attributes:  attrs,
+		ClusterName: old.ClusterName,
+		Name:        old.Name,
+		Seq:         nextSeq(ps.clock, old.Seq),
+	}
+	r.Sign(ps.identity)
+	ps.self = NewPeerFromRecord(r)
+}
+
+// SetBootstrap replaces the bootstrap peers and adds the new ones to the peerstore,
+// even if they were evicted recently. It returns the added peers. Peers removed from
+// the list are kept in the peerstore
+func (ps *PeerService) SetBootstrap(peers []config.Peer) []*Peer {
+	ps.lock.Lock()
+	ps.bootstrap = peers
+	ps.lock.Unlock()
+
+	var added []*Peer
+	for _, peer := range peers {
+		if ps.IsSelf(peer.Addr) {
+			continue
+		}
+		p := newBootstrapPeer(peer)
+		ps.peerstore.ClearTombstone(p.Addr())
+		if err := ps.AddPeer(p); err == nil {
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// Close stops tracking the connections and closes the peerstore
+func (ps *PeerService) Close() error {
+	ps.conns.close()
+	return ps.peerstore.Close()
+}
+
+// Clock returns the clock of the peer service
+func (ps *PeerService) Clock() clock.Clock {
+	return ps.clock
+}
+
+// Identity returns the keypair used to sign the record of self
+func (ps *PeerService) Identity() *identity.Identity {
+	return ps.identity
+}
+
 func (ps *PeerService) AddPeer(p *Peer) error {
-	return ps.peerstore.AddPeer(p)
+	if err := ps.accept(p); err != nil {
+		return err
+	}
+	prev := ps.previousPeer(p)
+	if err := ps.peerstore.AddPeer(p); err != nil {
+		return err
+	}
+	ps.emitMoved(prev, p.Addr())
+	ps.emitPeer(PeerAdded, p.Addr())
+	return nil
 }
 
+// AddPeers adds the given peers to the peerstore and returns the newly added peers.
+// Peers carrying a signed record replace the stored peer if the record is newer.
+// Peers not admitted by the cluster membership are ignored
 func (ps *PeerService) AddPeers(peers []*Peer) ([]*Peer, error) {
-	return ps.peerstore.AddPeers(peers, true)
+	var accepted []*Peer
+	for _, p := range peers {
+		if ps.accept(p) == nil {
+			accepted = append(accepted, p)
+		}
+	}
+	peers = accepted
+
+	// sequence numbers of the known records to detect updated peers and
+	// previous addresses of the known peers to detect moved peers
+	seqs := make(map[string]uint64)
+	prevs := make(map[string]*Peer)
+	for _, p := range peers {
+		if stored, err := ps.peerstore.GetPeer(p.Addr()); err == nil && stored.Record != nil {
+			seqs[p.Addr()] = stored.Record.Seq
+		}
+		if prev := ps.previousPeer(p); prev != nil {
+			prevs[p.Addr()] = prev
+		}
+	}
+
+	added, err := ps.peerstore.AddPeers(peers, true)
+	for addr, prev := range prevs {
+		ps.emitMoved(prev, addr)
+	}
+
+	isAdded := make(map[string]bool)
+	for _, p := range added {
+		isAdded[p.Addr()] = true
+		ps.emitPeer(PeerAdded, p.Addr())
+	}
+	for _, p := range peers {
+		if isAdded[p.Addr()] || p.Record == nil {
+			continue
+		}
+		stored, serr := ps.peerstore.GetPeer(p.Addr())
+		if serr == nil && stored.Record != nil && stored.Record.Seq != seqs[p.Addr()] {
+			ps.emit(PeerUpdated, stored)
+		}
+	}
+
+	return added, err
+}
+
+// PutRecord adds or updates the peer advertised by the signed record.
+// It returns true if the peer was not known before
+func (ps *PeerService) PutRecord(r *Record) (bool, error) {
+	if r.ID == ps.Self().ID {
+		return false, errors.New("cannot add self")
+	}
+	p := NewPeerFromRecord(r)
+	if err := ps.accept(p); err != nil {
+		return false, err
+	}
+	prev := ps.previousPeer(p)
+	added, err := ps.peerstore.PutRecord(r)
+	if err != nil {
+		return added, err
+	}
+	ps.emitMoved(prev, p.Addr())
+	if added {
+		ps.emitPeer(PeerAdded, r.Addrs[0])
+	} else {
+		ps.emitPeer(PeerUpdated, r.Addrs[0])
+	}
+	return added, nil
+}
+
+// accept returns an error if the peer is not admitted by the cluster membership
+// or claims the name of the local node in its cluster
+func (ps *PeerService) accept(p *Peer) error {
+	if !ps.membership.admits(p) {
+		return ErrPeerNotAdmitted
+	}
+	self := ps.Self()
+	if r := p.Record; r != nil && r.ClusterName == self.ClusterName && r.Name == self.Name && r.ID != self.ID {
+		return ErrPeerNameConflict
+	}
+	return nil
+}
+
+// previousPeer returns the stored peer with the same ID as the signed record of p
+func (ps *PeerService) previousPeer(p *Peer) *Peer {
+	if p.Record == nil {
+		return nil
+	}
+	prev, err := ps.peerstore.GetPeerByID(p.Record.ID)
+	if err != nil || prev.Addr() == p.Addr() {
+		return nil
+	}
+	return prev
+}
+
+// emitMoved notifies the removal of the previous address of a peer which moved to addr
+func (ps *PeerService) emitMoved(prev *Peer, addr string) {
+	if prev == nil {
+		return
+	}
+	if p, err := ps.peerstore.GetPeerByID(prev.ID); err != nil || p.Addr() != addr {
+		return
+	}
+	ps.logger.Info().Str("peer", prev.ID).Str("from", prev.Addr()).Str("to", addr).Msg("peer moved")
+	ps.emit(PeerRemoved, prev)
+}
+
+// GetPeer returns the peer with the given address
+func (ps *PeerService) GetPeer(addr string) (*Peer, error) {
+	return ps.peerstore.GetPeer(addr)
+}
+
+// GetPeerByID returns the peer with the given ID
+func (ps *PeerService) GetPeerByID(id string) (*Peer, error) {
+	return ps.peerstore.GetPeerByID(id)
+}
+
+// GetPeerByName returns the peer with the given name in the given cluster
+func (ps *PeerService) GetPeerByName(cluster, name string) (*Peer, error) {
+	return ps.peerstore.GetPeerByName(cluster, name)
+}
+
+// RemovePeer removes the peer from the peerstore and closes the connection to the peer
+func (ps *PeerService) RemovePeer(addr string) error {
+	p, err := ps.peerstore.GetPeer(addr)
+	if err != nil {
+		return err
+	}
+	if err := ps.peerstore.RemovePeer(p); err != nil {
+		return err
+	}
+	ps.emit(PeerRemoved, p)
+	return nil
+}
+
+// EvictPeer removes the peer, closes the connection to the peer and prevents the peer
+// from being re-added by other peers for the tombstone TTL
+func (ps *PeerService) EvictPeer(addr string) error {
+	p, err := ps.peerstore.GetPeer(addr)
+	if err != nil {
+		return err
+	}
+	if err := ps.peerstore.EvictPeer(addr, ps.eviction.TombstoneTTL); err != nil {
+		return err
+	}
+	ps.emit(PeerRemoved, p)
+	return nil
+}
+
+// ClearTombstone allows the evicted peer at addr to be added again before its tombstone expires
+func (ps *PeerService) ClearTombstone(addr string) error {
+	return ps.peerstore.ClearTombstone(addr)
+}
+
+// Evict evicts the peers violating the eviction policy and returns them.
+// Peers with a ready connection are alive and never evicted for their age.
+// Bootstrap peers whose tombstone expired are added back so that an isolated node
+// can rejoin the mesh
+func (ps *PeerService) Evict() []*Peer {
+	for _, p := range ps.peerstore.GetPeers() {
+		if p.GetState() == Ready {
+			ps.peerstore.MarkSeen(p.Addr())
+		}
+	}
+	evicted := ps.peerstore.Evict(ps.eviction)
+	for _, p := range evicted {
+		ps.emit(PeerRemoved, p)
+	}
+
+	ps.lock.RLock()
+	bootstrap := ps.bootstrap
+	ps.lock.RUnlock()
+
+	for _, peer := range bootstrap {
+		if ps.IsSelf(peer.Addr) || ps.peerstore.Tombstoned(peer.Addr) {
+			continue
+		}
+		ps.AddPeer(newBootstrapPeer(peer))
+	}
+
+	return evicted
+}
+
+// MarkSeen records a successful contact with the peer
+func (ps *PeerService) MarkSeen(addr string) error {
+	return ps.peerstore.MarkSeen(addr)
+}
+
+// MarkFailed records a failed attempt to reach the peer
+func (ps *PeerService) MarkFailed(addr string) (int, error) {
+	return ps.peerstore.MarkFailed(addr)
+}
+
+// GetLiveness returns the liveness of the peer
+func (ps *PeerService) GetLiveness(addr string) (Liveness, error) {
+	return ps.peerstore.GetLiveness(addr)
 }
 
 func (ps *PeerService) GetPeers() []*Peer {
@@ -84,18 +572,38 @@ func (ps *PeerService) GetNeighbors(ctx context.Context, p *Peer) ([]*Peer, erro
 		return nil, errors.New("connection not ready")
 	}
 
-	neihgbors, err := ps.client.GetPeers(ctx, conn, ps.self.PeerInfo)
+	neihgbors, err := ps.exchangePeers(ctx, conn, p.Addr())
 	if err != nil {
 		return nil, err
 	}
+	ps.membership.relay(p.Addr(), neihgbors)
+	ps.routes.update(p.Addr(), ps.views.connected(p.Addr()), ps.clock.Now())
 
 	return neihgbors, nil
 }
 
+// Route returns the shortest path to the peer at addr. Directly connected peers are
+// reached in a single hop, other peers through the neighbors reported by connected peers.
+// The peers in avoid are not used as hops. It returns ErrNoRoute if the peer is not reachable
+func (ps *PeerService) Route(addr string, avoid ...string) (*Route, error) {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return nil, ErrInvalidPeerAddress
+	}
+
+	var direct []string
+	for _, p := range ps.peerstore.GetPeers() {
+		if p.GetState() == Ready {
+			direct = append(direct, p.Addr())
+		}
+	}
+	return ps.routes.route(ps.Self().Addr(), addr, direct, avoid, ps.clock.Now())
+}
+
 // Connect connects to a peer and returns a client connection and updates peer connection at peerstore
 // throws error if connection fails
 func (ps *PeerService) Connect(addr string) (*grpc.ClientConn, error) {
-	if addr == ps.self.Addr() {
+	if ps.IsSelf(addr) {
 		return nil, errors.New("cannot connect to self")
 	}
 
@@ -103,15 +611,25 @@ func (ps *PeerService) Connect(addr string) (*grpc.ClientConn, error) {
 	if err != nil {
 		return nil, err
 	}
-	if p.GetState() == Ready {
-		return p.conn, nil
-	}
 
-	// connect to peer
-	conn, err := grpc.Dial(p.Addr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
-	ps.peerstore.SetPeerConnection(p.Addr(), conn)
+	return ps.conns.connect(p)
+}
+
+// WaitReady connects to the peer and blocks until the connection is ready or ctx is done
+func (ps *PeerService) WaitReady(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	conn, err := ps.Connect(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.conns.waitReady(ctx, conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
 
-	return conn, err
+// GetTransitions returns the latest connection state transitions of the peer, oldest first
+func (ps *PeerService) GetTransitions(addr string) ([]Transition, error) {
+	return ps.peerstore.GetTransitions(addr)
 }
 
 // Disconnect disconnects from a peer and updates peer connection at peerstore
@@ -123,8 +641,8 @@ func (ps *PeerService) Disconnect(addr string) error {
 	}
 
 	// update peer connection at peerstore
-	if p.GetState() == Ready {
-		p.conn.Close()
+	if conn := p.GetConnection(); conn != nil {
+		conn.Close()
 		ps.peerstore.SetPeerConnection(p.Addr(), nil)
 	}
 	return nil
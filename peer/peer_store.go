@@ -2,12 +2,11 @@ package peer
 
 import (
 	"errors"
-	"fmt"
-	"net"
-	"net/url"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/mr-shifu/grpc-p2p/address"
+	"github.com/mr-shifu/grpc-p2p/clock"
 	"google.golang.org/grpc"
 )
 
@@ -15,22 +14,60 @@ var (
 	ErrInvalidPeerAddress = errors.New("peerstore: invalid peer address")
 	ErrPeerNotFouund      = errors.New("peerstore: peer not found")
 	ErrPeerAlreadyExists  = errors.New("peerstore: failed to add peer. peer already exists")
+	ErrPeerIDMismatch     = errors.New("peerstore: peer record does not match the stored peer ID")
+	ErrPeerTombstoned     = errors.New("peerstore: failed to add peer. peer was recently evicted")
+	ErrPeerNameConflict   = errors.New("peerstore: peer name is already used by another peer")
 )
 
 type PeerStore struct {
 	lock  sync.RWMutex
 	peers map[string]*PeerInfo
 	conns map[string]*grpc.ClientConn
+
+	// ids maps the IDs of the peers with a signed record to their address
+	ids map[string]string
+	// names maps the cluster scoped names advertised by signed records to the ID of the peer
+	names map[string]string
+
+	// liveness of the peers in the peerstore
+	liveness map[string]*Liveness
+	// tombstones of evicted peers mapped to the expiry of the tombstone
+	tombstones map[string]time.Time
+
+	// latest connection state transitions of the peers
+	transitions map[string][]Transition
+
+	// clock timestamps liveness and tombstones
+	clock clock.Clock
 }
 
 func NewPeerStore() *PeerStore {
 	return &PeerStore{
-		lock:  sync.RWMutex{},
-		peers: make(map[string]*PeerInfo),
-		conns: make(map[string]*grpc.ClientConn),
+		lock:        sync.RWMutex{},
+		peers:       make(map[string]*PeerInfo),
+		conns:       make(map[string]*grpc.ClientConn),
+		ids:         make(map[string]string),
+		names:       make(map[string]string),
+		liveness:    make(map[string]*Liveness),
+		tombstones:  make(map[string]time.Time),
+		transitions: make(map[string][]Transition),
+		clock:       clock.Real,
 	}
 }
 
+// setClock replaces the clock timestamping liveness and tombstones
+func (ps *PeerStore) setClock(c clock.Clock) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.clock = c
+}
+
+// Close releases the resources of the peerstore. It is a no-op for the in-memory peerstore
+func (ps *PeerStore) Close() error {
+	return nil
+}
+
 func (ps *PeerStore) Exists(addr string) (bool, error) {
 	addr, err := validatePeerAddr(addr)
 	if err != nil {
@@ -46,29 +83,47 @@ func (ps *PeerStore) AddPeer(p *Peer) error {
 		return ErrInvalidPeerAddress
 	}
 
-	if exists := ps.exists(addr); exists {
-		return ErrPeerAlreadyExists
+	if err := verifyPeerRecord(p); err != nil {
+		return err
 	}
 
-	np := NewPeer(addr, p.Attributes())
-	ps.addPeer(np)
-
-	return nil
+	conn, err := ps.addPeer(newPeerInfo(addr, p.PeerInfo))
+	if conn != nil {
+		conn.Close()
+	}
+	return err
 }
 
+// AddPeers adds the given peers and returns the newly added peers.
+// Known peers carrying a newer signed record are updated but not returned.
+// Peers evicted recently are rejected until their tombstone expires
 func (ps *PeerStore) AddPeers(peers []*Peer, skip_errors bool) ([]*Peer, error) {
-	var refs []*Peer
+	added, _, err := ps.addPeers(peers, skip_errors)
+	return added, err
+}
+
+// addPeers adds the peers like AddPeers and also returns the known peers which
+// were updated with a newer signed record
+func (ps *PeerStore) addPeers(peers []*Peer, skip_errors bool) ([]*Peer, []*Peer, error) {
+	var refs, updated []*Peer
 	for _, peer := range peers {
-		if err := ps.AddPeer(peer); err != nil {
+		err := ps.AddPeer(peer)
+		if err == ErrPeerAlreadyExists && peer.Record != nil {
+			if ps.UpdatePeer(peer) == nil {
+				updated = append(updated, peer)
+			}
+			continue
+		}
+		if err != nil {
 			if skip_errors || err == ErrPeerAlreadyExists {
 				continue
 			} else {
-				return refs, err
+				return refs, updated, err
 			}
 		}
 		refs = append(refs, peer)
 	}
-	return refs, nil
+	return refs, updated, nil
 }
 
 func (ps *PeerStore) UpdatePeer(p *Peer) error {
@@ -77,14 +132,27 @@ func (ps *PeerStore) UpdatePeer(p *Peer) error {
 		return ErrInvalidPeerAddress
 	}
 
-	if exists := ps.exists(addr); !exists {
-		return ErrPeerNotFouund
+	if err := verifyPeerRecord(p); err != nil {
+		return err
 	}
 
-	np := NewPeer(addr, p.Attributes())
-	ps.addPeer(np)
+	conn, err := ps.updatePeer(newPeerInfo(addr, p.PeerInfo))
+	if conn != nil {
+		conn.Close()
+	}
+	return err
+}
 
-	return nil
+// PutRecord adds or updates the peer advertised by a signed record.
+// The record is only accepted if its signature verifies and its sequence number
+// is newer than the stored record. It returns true if a new peer is added
+func (ps *PeerStore) PutRecord(r *Record) (bool, error) {
+	p := NewPeerFromRecord(r)
+	err := ps.AddPeer(p)
+	if err == ErrPeerAlreadyExists {
+		return false, ps.UpdatePeer(p)
+	}
+	return err == nil, err
 }
 
 func (ps *PeerStore) RemovePeer(p *Peer) error {
@@ -97,7 +165,9 @@ func (ps *PeerStore) RemovePeer(p *Peer) error {
 		return ErrPeerNotFouund
 	}
 
-	ps.removePeer(addr)
+	if conn := ps.removePeer(addr); conn != nil {
+		conn.Close()
+	}
 
 	return nil
 }
@@ -113,12 +183,39 @@ func (ps *PeerStore) GetPeer(addr string) (*Peer, error) {
 		return nil, err
 	}
 
-	p := NewPeer(peerInfo.Addr, peerInfo.Attributes)
-	p.SetConnection(ps.conns[addr])
+	p := &Peer{PeerInfo: peerInfo}
+	conn, _ := ps.getPeerConnection(addr)
+	p.SetConnection(conn)
 
 	return p, nil
 }
 
+// GetPeerByID returns the peer whose signed record has the given ID
+func (ps *PeerStore) GetPeerByID(id string) (*Peer, error) {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	addr, ok := ps.ids[id]
+	if !ok {
+		return nil, ErrPeerNotFouund
+	}
+	p := &Peer{PeerInfo: newPeerInfo(addr, ps.peers[addr])}
+	p.SetConnection(ps.conns[addr])
+	return p, nil
+}
+
+// GetPeerByName returns the peer whose signed record has the given name in the given cluster
+func (ps *PeerStore) GetPeerByName(cluster, name string) (*Peer, error) {
+	ps.lock.RLock()
+	id, ok := ps.names[scopedName(cluster, name)]
+	ps.lock.RUnlock()
+
+	if !ok {
+		return nil, ErrPeerNotFouund
+	}
+	return ps.GetPeerByID(id)
+}
+
 func (ps *PeerStore) GetPeers() []*Peer {
 	return ps.getPeers()
 }
@@ -163,6 +260,44 @@ func (ps *PeerStore) SetPeerConnection(addr string, conn *grpc.ClientConn) (*grp
 	return ps.setPeerConnection(addr, conn)
 }
 
+// RecordTransition appends a connection state transition of the peer.
+// Only the latest transitions are kept
+func (ps *PeerStore) RecordTransition(addr string, t Transition) error {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return ErrInvalidPeerAddress
+	}
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.peers[addr]; !ok {
+		return ErrPeerNotFouund
+	}
+	transitions := append(ps.transitions[addr], t)
+	if len(transitions) > maxTransitions {
+		transitions = transitions[len(transitions)-maxTransitions:]
+	}
+	ps.transitions[addr] = transitions
+	return nil
+}
+
+// GetTransitions returns the latest connection state transitions of the peer, oldest first
+func (ps *PeerStore) GetTransitions(addr string) ([]Transition, error) {
+	addr, err := validatePeerAddr(addr)
+	if err != nil {
+		return nil, ErrInvalidPeerAddress
+	}
+
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	if _, ok := ps.peers[addr]; !ok {
+		return nil, ErrPeerNotFouund
+	}
+	return append([]Transition(nil), ps.transitions[addr]...), nil
+}
+
 func (ps *PeerStore) exists(addr string) bool {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
@@ -171,6 +306,13 @@ func (ps *PeerStore) exists(addr string) bool {
 	return ok
 }
 
+func (ps *PeerStore) count() int {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	return len(ps.peers)
+}
+
 func (ps *PeerStore) getPeer(addr string) (*PeerInfo, error) {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
@@ -178,7 +320,7 @@ func (ps *PeerStore) getPeer(addr string) (*PeerInfo, error) {
 	if _, ok := ps.peers[addr]; !ok {
 		return nil, ErrPeerNotFouund
 	}
-	return ps.peers[addr], nil
+	return newPeerInfo(addr, ps.peers[addr]), nil
 }
 
 func (ps *PeerStore) getPeers() []*Peer {
@@ -186,26 +328,141 @@ func (ps *PeerStore) getPeers() []*Peer {
 	defer ps.lock.Unlock()
 
 	var peers []*Peer
-	for _, peer := range ps.peers {
-		p := NewPeer(peer.Addr, peer.Attributes)
-		p.SetConnection(ps.conns[peer.Addr])
+	for addr, peer := range ps.peers {
+		p := &Peer{PeerInfo: newPeerInfo(addr, peer)}
+		p.SetConnection(ps.conns[addr])
 		peers = append(peers, p)
 	}
 	return peers
 }
 
-func (ps *PeerStore) addPeer(p *Peer) {
+// addPeer adds the peer info unless its address is known or tombstoned. It returns the
+// connection to be closed by the caller if the peer moved from another address
+func (ps *PeerStore) addPeer(info *PeerInfo) (*grpc.ClientConn, error) {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
 
-	ps.peers[p.Addr()] = p.PeerInfo
+	// the address is checked under the same lock as the insertion so that
+	// concurrent adds of the same peer cannot both succeed
+	if _, ok := ps.peers[info.Addr]; ok {
+		return nil, ErrPeerAlreadyExists
+	}
+	if expiry, ok := ps.tombstones[info.Addr]; ok {
+		if !ps.clock.Now().After(expiry) {
+			return nil, ErrPeerTombstoned
+		}
+		delete(ps.tombstones, info.Addr)
+	}
+
+	conn, err := ps.relocateLocked(info)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.peers[info.Addr] = info
+	ps.liveness[info.Addr] = &Liveness{LastSeen: ps.clock.Now()}
+	ps.indexLocked(info)
+	return conn, nil
 }
 
-func (ps *PeerStore) removePeer(addr string) {
+// updatePeer replaces the stored peer info. A signed record can only be replaced
+// by a newer record of the same peer. It returns the connection to be closed by
+// the caller if the peer moved from another address
+func (ps *PeerStore) updatePeer(info *PeerInfo) (*grpc.ClientConn, error) {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
 
+	stored, ok := ps.peers[info.Addr]
+	if !ok {
+		return nil, ErrPeerNotFouund
+	}
+	if stored.Record != nil {
+		if info.Record == nil {
+			return nil, ErrStaleRecord
+		}
+		if info.Record.ID != stored.Record.ID {
+			return nil, ErrPeerIDMismatch
+		}
+		if info.Record.Seq <= stored.Record.Seq {
+			return nil, ErrStaleRecord
+		}
+	}
+
+	conn, err := ps.relocateLocked(info)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.unindexLocked(stored)
+	ps.peers[info.Addr] = info
+	ps.indexLocked(info)
+	return conn, nil
+}
+
+// relocateLocked checks that the name of a signed record is not used by another
+// peer of the same cluster and removes the peer from its previous address if the record moved the
+// known peer to a new address. The record must be newer than the record stored
+// at the previous address
+func (ps *PeerStore) relocateLocked(info *PeerInfo) (*grpc.ClientConn, error) {
+	if info.Record == nil {
+		return nil, nil
+	}
+	if id, ok := ps.names[scopedName(info.ClusterName, info.Name)]; ok && info.Name != "" && id != info.ID {
+		return nil, ErrPeerNameConflict
+	}
+
+	old, ok := ps.ids[info.ID]
+	if !ok || old == info.Addr {
+		return nil, nil
+	}
+	if stored := ps.peers[old]; stored.Record != nil && info.Record.Seq <= stored.Record.Seq {
+		return nil, ErrStaleRecord
+	}
+	return ps.deleteLocked(old), nil
+}
+
+// indexLocked indexes the peer by ID and by name
+func (ps *PeerStore) indexLocked(info *PeerInfo) {
+	if info.Record == nil {
+		return
+	}
+	ps.ids[info.ID] = info.Addr
+	if info.Name != "" {
+		ps.names[scopedName(info.ClusterName, info.Name)] = info.ID
+	}
+}
+
+func (ps *PeerStore) unindexLocked(info *PeerInfo) {
+	if info.Record == nil {
+		return
+	}
+	if ps.ids[info.ID] == info.Addr {
+		delete(ps.ids, info.ID)
+	}
+	if name := scopedName(info.ClusterName, info.Name); ps.names[name] == info.ID {
+		delete(ps.names, name)
+	}
+}
+
+// deleteLocked deletes the peer and returns its connection to be closed by the caller
+func (ps *PeerStore) deleteLocked(addr string) *grpc.ClientConn {
+	conn := ps.conns[addr]
+	if info, ok := ps.peers[addr]; ok {
+		ps.unindexLocked(info)
+	}
 	delete(ps.peers, addr)
+	delete(ps.conns, addr)
+	delete(ps.liveness, addr)
+	delete(ps.transitions, addr)
+	return conn
+}
+
+// removePeer removes the peer and returns its connection to be closed by the caller
+func (ps *PeerStore) removePeer(addr string) *grpc.ClientConn {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	return ps.deleteLocked(addr)
 }
 
 func (ps *PeerStore) getPeerConnection(addr string) (*grpc.ClientConn, error) {
@@ -223,34 +480,45 @@ func (ps *PeerStore) setPeerConnection(addr string, conn *grpc.ClientConn) (*grp
 	return conn, nil
 }
 
-func validatePeerAddr(addr string) (string, error) {
-	host, err := parseIP(addr)
-	if err == nil && host != "" {
-		return host, nil
+// newPeerInfo returns a copy of info stored under the given address.
+// The cluster and name advertised by the signed record take precedence over the given ones
+func newPeerInfo(addr string, info *PeerInfo) *PeerInfo {
+	id, cluster, name := info.ID, info.ClusterName, info.Name
+	if info.Record != nil {
+		id, cluster, name = info.Record.ID, info.Record.ClusterName, info.Record.Name
 	}
-
-	u, err := url.Parse(addr)
-	if err != nil {
-		return "", err
+	return &PeerInfo{
+		Addr:        addr,
+		Attributes:  info.Attributes,
+		ClusterName: cluster,
+		Name:        name,
+		ID:          id,
+		Record:      info.Record,
 	}
-	host = strings.TrimPrefix(u.Host, "www.")
+}
 
-	return host, nil
+// scopedName returns the name of a peer unique within its cluster as in the config,
+// e.g. cluster_0/peer0
+func scopedName(cluster, name string) string {
+	return cluster + "/" + name
 }
 
-func parseIP(addr string) (string, error) {
-	host, port, err := net.SplitHostPort(addr)
-	if err != nil {
-		if ip := net.ParseIP(addr); ip != nil {
-			return ip.String(), nil
-		}
-		return "", ErrInvalidPeerAddress
+// verifyPeerRecord verifies the signed record of the peer if any.
+// Peers without a record (e.g. bootstrap peers) are accepted as is
+func verifyPeerRecord(p *Peer) error {
+	if p.Record == nil {
+		return nil
 	}
-	if ip := net.ParseIP(host); ip == nil {
-		return "", ErrInvalidPeerAddress
+	if err := p.Record.Verify(); err != nil {
+		return err
 	}
-	if p, err := net.LookupPort("tcp", port); err != nil || p < 0 {
-		return "", ErrInvalidPeerAddress
+	if !p.Record.HasAddr(p.Addr()) {
+		return ErrInvalidRecord
 	}
-	return fmt.Sprintf("%s:%s", host, port), nil
+	return nil
+}
+
+// validatePeerAddr returns the normalized address used as key of the peerstore
+func validatePeerAddr(addr string) (string, error) {
+	return address.Normalize(addr)
 }
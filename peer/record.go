@@ -0,0 +1,192 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+
+	"github.com/mr-shifu/grpc-p2p/identity"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/mr-shifu/grpc-p2p/transport"
+)
+
+var (
+	ErrInvalidRecord       = errors.New("peerstore: invalid peer record signature")
+	ErrStaleRecord         = errors.New("peerstore: peer record is not newer than the stored one")
+	ErrCertificateMismatch = errors.New("peerstore: peer address does not match certificate")
+)
+
+// recordDomain separates record signatures from any other use of the node key
+const recordDomain = "grpc-p2p/peer-record"
+
+// Record is the identity of a peer signed by the peer itself.
+// Records are exchanged between peers and only accepted if the signature verifies
+type Record struct {
+	ID         string
+	PublicKey  ed25519.PublicKey
+	Addrs      []string
+	Attributes map[string]string
+	// ClusterName is the cluster the peer is a member of
+	ClusterName string
+	// Name is the human readable name of the peer. It is unique in the mesh
+	Name      string
+	Seq       uint64
+	Signature []byte
+}
+
+// SignRecord creates a record for the given identity, addresses and attributes signed by the identity
+func SignRecord(id *identity.Identity, addrs []string, attrs map[string]string, seq uint64) *Record {
+	r := &Record{
+		Addrs:      addrs,
+		Attributes: attrs,
+		Seq:        seq,
+	}
+	r.Sign(id)
+	return r
+}
+
+// Sign sets the ID and the public key of the record to the given identity and signs the record
+func (r *Record) Sign(id *identity.Identity) {
+	r.ID = id.ID()
+	r.PublicKey = id.PublicKey()
+	r.Signature = id.Sign(r.payload())
+}
+
+// Verify checks that the ID is derived from the public key and the signature is valid
+func (r *Record) Verify() error {
+	if len(r.PublicKey) != ed25519.PublicKeySize || len(r.Addrs) == 0 {
+		return ErrInvalidRecord
+	}
+	if identity.IDFromPublicKey(r.PublicKey) != r.ID {
+		return ErrInvalidRecord
+	}
+	if !ed25519.Verify(r.PublicKey, r.payload(), r.Signature) {
+		return ErrInvalidRecord
+	}
+	return nil
+}
+
+// HasAddr returns true if the record advertises the given address
+func (r *Record) HasAddr(addr string) bool {
+	for _, a := range r.Addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// payload returns the deterministic encoding of the record signed by the peer
+func (r *Record) payload() []byte {
+	var b bytes.Buffer
+	writeField(&b, []byte(recordDomain))
+	writeField(&b, []byte(r.ID))
+	writeField(&b, r.PublicKey)
+
+	binary.Write(&b, binary.BigEndian, uint32(len(r.Addrs)))
+	for _, addr := range r.Addrs {
+		writeField(&b, []byte(addr))
+	}
+
+	keys := make([]string, 0, len(r.Attributes))
+	for k := range r.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	binary.Write(&b, binary.BigEndian, uint32(len(keys)))
+	for _, k := range keys {
+		writeField(&b, []byte(k))
+		writeField(&b, []byte(r.Attributes[k]))
+	}
+
+	binary.Write(&b, binary.BigEndian, r.Seq)
+
+	// optional fields are only encoded up to the last one set so that records
+	// signed by older peers without these fields still verify
+	optional := []string{r.ClusterName, r.Name}
+	for len(optional) > 0 && optional[len(optional)-1] == "" {
+		optional = optional[:len(optional)-1]
+	}
+	for _, v := range optional {
+		writeField(&b, []byte(v))
+	}
+	return b.Bytes()
+}
+
+func writeField(b *bytes.Buffer, v []byte) {
+	binary.Write(b, binary.BigEndian, uint32(len(v)))
+	b.Write(v)
+}
+
+// RecordToPb converts a record to its protobuf representation
+func RecordToPb(r *Record) *p2p_pb.PeerRecord {
+	if r == nil {
+		return nil
+	}
+	var attrs []*p2p_pb.Attribute
+	for k, v := range r.Attributes {
+		attrs = append(attrs, &p2p_pb.Attribute{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return &p2p_pb.PeerRecord{
+		ID:          r.ID,
+		PublicKey:   r.PublicKey,
+		Addresses:   r.Addrs,
+		Attributes:  attrs,
+		Seq:         r.Seq,
+		Signature:   r.Signature,
+		ClusterName: r.ClusterName,
+		Name:        r.Name,
+	}
+}
+
+// RecordFromPb converts a protobuf record to a record. It does not verify the record
+func RecordFromPb(pb *p2p_pb.PeerRecord) *Record {
+	if pb == nil {
+		return nil
+	}
+	attrs := make(map[string]string)
+	for _, attr := range pb.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return &Record{
+		ID:          pb.ID,
+		PublicKey:   pb.PublicKey,
+		Addrs:       pb.Addresses,
+		Attributes:  attrs,
+		ClusterName: pb.ClusterName,
+		Name:        pb.Name,
+		Seq:         pb.Seq,
+		Signature:   pb.Signature,
+	}
+}
+
+// VerifyCaller returns the signed record sent by the caller of an incoming rpc after
+// verifying its signature. If the caller presented a verified certificate, the
+// advertised address must be covered by the certificate
+func VerifyCaller(ctx context.Context, pb *p2p_pb.PeerRecord) (*Record, error) {
+	r := RecordFromPb(pb)
+	if r == nil {
+		return nil, ErrInvalidRecord
+	}
+	if err := r.Verify(); err != nil {
+		return nil, err
+	}
+
+	if cert, ok := transport.PeerCertificate(ctx); ok {
+		host, _, err := net.SplitHostPort(r.Addrs[0])
+		if err != nil {
+			return nil, ErrCertificateMismatch
+		}
+		if err := cert.VerifyHostname(host); err != nil {
+			return nil, ErrCertificateMismatch
+		}
+	}
+	return r, nil
+}
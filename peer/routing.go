@@ -0,0 +1,123 @@
+package peer
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultLinkTTL = 1 * time.Minute
+
+var ErrNoRoute = errors.New("peer: no route to peer")
+
+// Route is the path chosen to reach a peer
+type Route struct {
+	// Dest is the address of the destination
+	Dest string
+	// Hops are the addresses of the peers on the path from the next hop to Dest.
+	// A route to a directly connected peer has a single hop
+	Hops []string
+}
+
+// NextHop returns the address of the peer messages to Dest are sent to
+func (r *Route) NextHop() string {
+	return r.Hops[0]
+}
+
+// Direct returns true if Dest is directly connected
+func (r *Route) Direct() bool {
+	return len(r.Hops) == 1
+}
+
+// String returns the path of the route, e.g. "b:8000 -> c:8000"
+func (r *Route) String() string {
+	return strings.Join(r.Hops, " -> ")
+}
+
+// links are the addresses of the peers a peer reported to be connected to
+type links struct {
+	addrs     []string
+	updatedAt time.Time
+}
+
+// routingTable keeps the links reported by peers and finds the shortest paths over them
+type routingTable struct {
+	lock  sync.Mutex
+	ttl   time.Duration
+	links map[string]*links
+}
+
+func newRoutingTable(ttl time.Duration) *routingTable {
+	if ttl <= 0 {
+		ttl = defaultLinkTTL
+	}
+	return &routingTable{
+		ttl:   ttl,
+		links: make(map[string]*links),
+	}
+}
+
+// update replaces the links reported by the peer at addr
+func (t *routingTable) update(addr string, connected []string, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.links[addr] = &links{addrs: connected, updatedAt: now}
+}
+
+// route returns the shortest path from self to dest whose first hop is one of the
+// directly connected peers. The peers in avoid are not used as hops
+func (t *routingTable) route(self, dest string, direct []string, avoid []string, now time.Time) (*Route, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// previous maps every visited peer to the previous hop on its path
+	previous := map[string]string{self: ""}
+	for _, addr := range avoid {
+		previous[addr] = ""
+	}
+
+	var queue []string
+	for _, addr := range direct {
+		if _, ok := previous[addr]; ok {
+			continue
+		}
+		previous[addr] = self
+		queue = append(queue, addr)
+	}
+
+	for len(queue) > 0 {
+		addr := queue[0]
+		queue = queue[1:]
+		if addr == dest {
+			return &Route{Dest: dest, Hops: path(previous, self, dest)}, nil
+		}
+
+		l, ok := t.links[addr]
+		if !ok {
+			continue
+		}
+		if now.Sub(l.updatedAt) >= t.ttl {
+			delete(t.links, addr)
+			continue
+		}
+		for _, next := range l.addrs {
+			if _, ok := previous[next]; ok {
+				continue
+			}
+			previous[next] = addr
+			queue = append(queue, next)
+		}
+	}
+	return nil, ErrNoRoute
+}
+
+// path walks back the previous hops from dest to self and returns the hops in order
+func path(previous map[string]string, self, dest string) []string {
+	var hops []string
+	for addr := dest; addr != self; addr = previous[addr] {
+		hops = append([]string{addr}, hops...)
+	}
+	return hops
+}
@@ -0,0 +1,44 @@
+package peer
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Store is the storage of the peers known by the local node and their connections.
+// PeerStore is the in-memory implementation and FileStore persists the peers on disk.
+// Implementations must be safe for concurrent use and pass the storetest conformance suite
+type Store interface {
+	Exists(addr string) (bool, error)
+	AddPeer(p *Peer) error
+	AddPeers(peers []*Peer, skip_errors bool) ([]*Peer, error)
+	UpdatePeer(p *Peer) error
+	PutRecord(r *Record) (bool, error)
+	RemovePeer(p *Peer) error
+	GetPeer(addr string) (*Peer, error)
+	GetPeerByID(id string) (*Peer, error)
+	GetPeerByName(cluster, name string) (*Peer, error)
+	GetPeers() []*Peer
+	GetPeersWithAttributes(attrs map[string]string) []*Peer
+	GetPeerConnection(addr string) (*grpc.ClientConn, error)
+	SetPeerConnection(addr string, conn *grpc.ClientConn) (*grpc.ClientConn, error)
+
+	MarkSeen(addr string) error
+	MarkFailed(addr string) (int, error)
+	GetLiveness(addr string) (Liveness, error)
+	Evict(policy EvictionPolicy) []*Peer
+	EvictPeer(addr string, ttl time.Duration) error
+	Tombstoned(addr string) bool
+	ClearTombstone(addr string) error
+
+	RecordTransition(addr string, t Transition) error
+	GetTransitions(addr string) ([]Transition, error)
+
+	Close() error
+}
+
+var (
+	_ Store = (*PeerStore)(nil)
+	_ Store = (*FileStore)(nil)
+)
@@ -0,0 +1,30 @@
+package peer_test
+
+import (
+	"testing"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	"github.com/mr-shifu/grpc-p2p/peer/storetest"
+)
+
+func TestPeerStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) peer.Store {
+		return peer.NewPeerStore()
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	open := func(t *testing.T, dir string) peer.Store {
+		s, err := peer.NewFileStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+
+	storetest.Run(t, func(t *testing.T) peer.Store {
+		return open(t, t.TempDir())
+	})
+	storetest.RunPersistent(t, open)
+}
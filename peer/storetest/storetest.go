@@ -0,0 +1,508 @@
+// Package storetest provides the conformance suite every peer.Store implementation must pass
+package storetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/identity"
+	"github.com/mr-shifu/grpc-p2p/peer"
+)
+
+// Run runs the conformance suite against stores created by newStore.
+// Every subtest gets a new empty store
+func Run(t *testing.T, newStore func(t *testing.T) peer.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s peer.Store)
+	}{
+		{"AddPeer", testAddPeer},
+		{"AddPeers", testAddPeers},
+		{"AddPeersSkipErrors", testAddPeersSkipErrors},
+		{"UpdatePeer", testUpdatePeer},
+		{"RemovePeer", testRemovePeer},
+		{"GetPeersWithAttributes", testGetPeersWithAttributes},
+		{"Records", testRecords},
+		{"Identities", testIdentities},
+		{"Connections", testConnections},
+		{"Liveness", testLiveness},
+		{"Evict", testEvict},
+		{"EvictPeer", testEvictPeer},
+		{"Transitions", testTransitions},
+		{"Concurrency", testConcurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			defer s.Close()
+			tt.fn(t, s)
+		})
+	}
+}
+
+// RunPersistent checks that a persistent store reloads its peers.
+// open must return a store backed by dir
+func RunPersistent(t *testing.T, open func(t *testing.T, dir string) peer.Store) {
+	dir := t.TempDir()
+	id := newIdentity(t)
+
+	s := open(t, dir)
+	mustAdd(t, s, addr(1), map[string]string{"role": "a"})
+	mustAdd(t, s, addr(2), nil)
+	if _, err := s.PutRecord(peer.SignRecord(id, []string{addr(3)}, nil, 1)); err != nil {
+		t.Fatalf("PutRecord: %v", err)
+	}
+	s.MarkFailed(addr(1))
+	s.MarkFailed(addr(1))
+	if err := s.RemovePeer(peer.NewPeer(addr(2), nil)); err != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+	mustAdd(t, s, addr(4), nil)
+	if err := s.EvictPeer(addr(4), time.Hour); err != nil {
+		t.Fatalf("EvictPeer: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// the peers are considered seen when reloaded however long the store was closed
+	time.Sleep(50 * time.Millisecond)
+	reopened := time.Now()
+	s = open(t, dir)
+	defer s.Close()
+
+	if evicted := s.Evict(peer.EvictionPolicy{MaxAge: 40 * time.Millisecond}); len(evicted) != 0 {
+		t.Errorf("reloaded peers evicted by age: %v", evicted)
+	}
+
+	if n := len(s.GetPeers()); n != 2 {
+		t.Fatalf("reloaded %d peers, want 2", n)
+	}
+	p, err := s.GetPeer(addr(1))
+	if err != nil {
+		t.Fatalf("GetPeer: %v", err)
+	}
+	if p.Attributes()["role"] != "a" {
+		t.Errorf("attributes not reloaded: %v", p.Attributes())
+	}
+	if !s.Tombstoned(addr(4)) {
+		t.Errorf("tombstone not reloaded")
+	}
+	if err := s.AddPeer(peer.NewPeer(addr(4), nil)); err != peer.ErrPeerTombstoned {
+		t.Errorf("AddPeer of a reloaded tombstone: %v, want %v", err, peer.ErrPeerTombstoned)
+	}
+	if l, _ := s.GetLiveness(addr(1)); l.Failures != 2 || l.LastSeen.Before(reopened) {
+		t.Errorf("reloaded liveness %+v, want 2 failures seen after %v", l, reopened)
+	}
+	p, err = s.GetPeer(addr(3))
+	if err != nil {
+		t.Fatalf("GetPeer: %v", err)
+	}
+	if p.Record == nil || p.Record.Verify() != nil || p.ID != id.ID() {
+		t.Errorf("record not reloaded")
+	}
+}
+
+func testAddPeer(t *testing.T, s peer.Store) {
+	mustAdd(t, s, addr(1), map[string]string{"k": "v"})
+
+	if err := s.AddPeer(peer.NewPeer(addr(1), nil)); err != peer.ErrPeerAlreadyExists {
+		t.Errorf("AddPeer duplicate = %v, want %v", err, peer.ErrPeerAlreadyExists)
+	}
+	if err := s.AddPeer(peer.NewPeer("%zz", nil)); err != peer.ErrInvalidPeerAddress {
+		t.Errorf("AddPeer invalid = %v, want %v", err, peer.ErrInvalidPeerAddress)
+	}
+
+	ok, err := s.Exists(addr(1))
+	if err != nil || !ok {
+		t.Errorf("Exists = %v, %v, want true", ok, err)
+	}
+	p, err := s.GetPeer(addr(1))
+	if err != nil {
+		t.Fatalf("GetPeer: %v", err)
+	}
+	if p.Addr() != addr(1) || p.Attributes()["k"] != "v" {
+		t.Errorf("GetPeer = %s %v", p.Addr(), p.Attributes())
+	}
+	if _, err := s.GetPeer(addr(2)); err != peer.ErrPeerNotFouund {
+		t.Errorf("GetPeer missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+}
+
+func testAddPeers(t *testing.T, s peer.Store) {
+	mustAdd(t, s, addr(1), nil)
+
+	added, err := s.AddPeers([]*peer.Peer{
+		peer.NewPeer(addr(1), nil),
+		peer.NewPeer(addr(2), nil),
+		peer.NewPeer(addr(3), nil),
+	}, true)
+	if err != nil {
+		t.Fatalf("AddPeers: %v", err)
+	}
+	if len(added) != 2 {
+		t.Errorf("AddPeers added %d peers, want 2", len(added))
+	}
+	if n := len(s.GetPeers()); n != 3 {
+		t.Errorf("GetPeers returned %d peers, want 3", n)
+	}
+}
+
+func testAddPeersSkipErrors(t *testing.T, s peer.Store) {
+	peers := []*peer.Peer{
+		peer.NewPeer(addr(1), nil),
+		peer.NewPeer("not an address", nil),
+		peer.NewPeer(addr(2), nil),
+	}
+
+	added, err := s.AddPeers(peers, true)
+	if err != nil {
+		t.Fatalf("AddPeers with skip_errors: %v", err)
+	}
+	if len(added) != 2 {
+		t.Errorf("AddPeers added %d peers, want 2", len(added))
+	}
+
+	if _, err := s.AddPeers(peers, false); err == nil {
+		t.Errorf("AddPeers without skip_errors accepted an invalid address")
+	}
+}
+
+func testUpdatePeer(t *testing.T, s peer.Store) {
+	if err := s.UpdatePeer(peer.NewPeer(addr(1), nil)); err != peer.ErrPeerNotFouund {
+		t.Errorf("UpdatePeer missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	mustAdd(t, s, addr(1), map[string]string{"k": "v1"})
+	if err := s.UpdatePeer(peer.NewPeer(addr(1), map[string]string{"k": "v2"})); err != nil {
+		t.Fatalf("UpdatePeer: %v", err)
+	}
+	p, _ := s.GetPeer(addr(1))
+	if p.Attributes()["k"] != "v2" {
+		t.Errorf("UpdatePeer did not update attributes: %v", p.Attributes())
+	}
+}
+
+func testRemovePeer(t *testing.T, s peer.Store) {
+	if err := s.RemovePeer(peer.NewPeer(addr(1), nil)); err != peer.ErrPeerNotFouund {
+		t.Errorf("RemovePeer missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	mustAdd(t, s, addr(1), nil)
+	if err := s.RemovePeer(peer.NewPeer(addr(1), nil)); err != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+	if ok, _ := s.Exists(addr(1)); ok {
+		t.Errorf("peer exists after RemovePeer")
+	}
+	if s.Tombstoned(addr(1)) {
+		t.Errorf("RemovePeer must not tombstone the peer")
+	}
+}
+
+func testGetPeersWithAttributes(t *testing.T, s peer.Store) {
+	mustAdd(t, s, addr(1), map[string]string{"role": "db", "zone": "a"})
+	mustAdd(t, s, addr(2), map[string]string{"role": "db", "zone": "b"})
+	mustAdd(t, s, addr(3), map[string]string{"role": "web"})
+
+	if n := len(s.GetPeersWithAttributes(nil)); n != 3 {
+		t.Errorf("no filter returned %d peers, want 3", n)
+	}
+	if n := len(s.GetPeersWithAttributes(map[string]string{"role": "db"})); n != 2 {
+		t.Errorf("role=db returned %d peers, want 2", n)
+	}
+	if n := len(s.GetPeersWithAttributes(map[string]string{"role": "db", "zone": "b"})); n != 1 {
+		t.Errorf("role=db,zone=b returned %d peers, want 1", n)
+	}
+}
+
+func testRecords(t *testing.T, s peer.Store) {
+	id := newIdentity(t)
+
+	added, err := s.PutRecord(peer.SignRecord(id, []string{addr(1)}, map[string]string{"v": "1"}, 1))
+	if err != nil || !added {
+		t.Fatalf("PutRecord = %v, %v, want true", added, err)
+	}
+	p, _ := s.GetPeer(addr(1))
+	if p.ID != id.ID() || p.Record == nil {
+		t.Fatalf("record not stored")
+	}
+
+	tampered := peer.SignRecord(id, []string{addr(1)}, map[string]string{"v": "2"}, 2)
+	tampered.Attributes["v"] = "3"
+	if _, err := s.PutRecord(tampered); err != peer.ErrInvalidRecord {
+		t.Errorf("PutRecord tampered = %v, want %v", err, peer.ErrInvalidRecord)
+	}
+	if _, err := s.PutRecord(peer.SignRecord(id, []string{addr(1)}, nil, 1)); err != peer.ErrStaleRecord {
+		t.Errorf("PutRecord stale = %v, want %v", err, peer.ErrStaleRecord)
+	}
+	if _, err := s.PutRecord(peer.SignRecord(newIdentity(t), []string{addr(1)}, nil, 5)); err != peer.ErrPeerIDMismatch {
+		t.Errorf("PutRecord other identity = %v, want %v", err, peer.ErrPeerIDMismatch)
+	}
+	if err := s.UpdatePeer(peer.NewPeer(addr(1), nil)); err != peer.ErrStaleRecord {
+		t.Errorf("UpdatePeer unsigned = %v, want %v", err, peer.ErrStaleRecord)
+	}
+
+	// newer records are applied through AddPeers without being reported as added
+	newer := peer.NewPeerFromRecord(peer.SignRecord(id, []string{addr(1)}, map[string]string{"v": "2"}, 2))
+	added2, _ := s.AddPeers([]*peer.Peer{newer}, true)
+	if len(added2) != 0 {
+		t.Errorf("AddPeers reported an updated peer as added")
+	}
+	p, _ = s.GetPeer(addr(1))
+	if p.Attributes()["v"] != "2" || p.Record.Seq != 2 {
+		t.Errorf("newer record not applied: %v", p.Attributes())
+	}
+}
+
+func testIdentities(t *testing.T, s peer.Store) {
+	id := newIdentity(t)
+	sign := func(id *identity.Identity, a, name string, seq uint64) *peer.Record {
+		r := &peer.Record{Addrs: []string{a}, ClusterName: "c", Name: name, Seq: seq}
+		r.Sign(id)
+		return r
+	}
+
+	if _, err := s.PutRecord(sign(id, addr(1), "alice", 1)); err != nil {
+		t.Fatalf("PutRecord: %v", err)
+	}
+	p, err := s.GetPeerByID(id.ID())
+	if err != nil || p.Addr() != addr(1) {
+		t.Fatalf("GetPeerByID = %v, %v", p, err)
+	}
+	p, err = s.GetPeerByName("c", "alice")
+	if err != nil || p.ID != id.ID() || p.Name != "alice" {
+		t.Fatalf("GetPeerByName = %v, %v", p, err)
+	}
+
+	// another peer cannot claim a used name
+	if _, err := s.PutRecord(sign(newIdentity(t), addr(2), "alice", 1)); err != peer.ErrPeerNameConflict {
+		t.Errorf("PutRecord conflicting name = %v, want %v", err, peer.ErrPeerNameConflict)
+	}
+
+	// names are unique within a cluster only
+	other := &peer.Record{Addrs: []string{addr(4)}, ClusterName: "d", Name: "alice", Seq: 1}
+	other.Sign(newIdentity(t))
+	if _, err := s.PutRecord(other); err != nil {
+		t.Errorf("PutRecord same name in another cluster: %v", err)
+	}
+	if p, err := s.GetPeerByName("d", "alice"); err != nil || p.ID != other.ID {
+		t.Errorf("GetPeerByName in another cluster = %v, %v", p, err)
+	}
+	s.RemovePeer(peer.NewPeer(addr(4), nil))
+
+	// a stale record on a new address does not move the peer
+	if _, err := s.PutRecord(sign(id, addr(3), "alice", 1)); err != peer.ErrStaleRecord {
+		t.Errorf("PutRecord stale on new address = %v, want %v", err, peer.ErrStaleRecord)
+	}
+
+	// a newer record on a new address moves the peer
+	if _, err := s.PutRecord(sign(id, addr(3), "alice", 2)); err != nil {
+		t.Fatalf("PutRecord on new address: %v", err)
+	}
+	if ok, _ := s.Exists(addr(1)); ok {
+		t.Errorf("peer still stored at its previous address")
+	}
+	p, err = s.GetPeerByID(id.ID())
+	if err != nil || p.Addr() != addr(3) {
+		t.Errorf("GetPeerByID after move = %v, %v, want %s", p, err, addr(3))
+	}
+
+	// the name is released once the peer is removed
+	if err := s.RemovePeer(peer.NewPeer(addr(3), nil)); err != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+	if _, err := s.GetPeerByName("c", "alice"); err != peer.ErrPeerNotFouund {
+		t.Errorf("GetPeerByName removed = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+	if _, err := s.PutRecord(sign(newIdentity(t), addr(2), "alice", 1)); err != nil {
+		t.Errorf("PutRecord released name: %v", err)
+	}
+}
+
+func testConnections(t *testing.T, s peer.Store) {
+	if _, err := s.GetPeerConnection(addr(1)); err != peer.ErrPeerNotFouund {
+		t.Errorf("GetPeerConnection missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	mustAdd(t, s, addr(1), nil)
+	conn, err := s.GetPeerConnection(addr(1))
+	if err != nil || conn != nil {
+		t.Errorf("GetPeerConnection = %v, %v, want nil connection", conn, err)
+	}
+	p, _ := s.GetPeer(addr(1))
+	if p.GetState() != peer.NoConnection {
+		t.Errorf("state = %s, want %s", p.GetState(), peer.NoConnection)
+	}
+}
+
+func testLiveness(t *testing.T, s peer.Store) {
+	if _, err := s.MarkFailed(addr(1)); err != peer.ErrPeerNotFouund {
+		t.Errorf("MarkFailed missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	before := time.Now()
+	mustAdd(t, s, addr(1), nil)
+	l, err := s.GetLiveness(addr(1))
+	if err != nil {
+		t.Fatalf("GetLiveness: %v", err)
+	}
+	if l.LastSeen.Before(before.Add(-time.Second)) || l.Failures != 0 {
+		t.Errorf("initial liveness = %+v", l)
+	}
+
+	s.MarkFailed(addr(1))
+	if n, _ := s.MarkFailed(addr(1)); n != 2 {
+		t.Errorf("MarkFailed = %d, want 2", n)
+	}
+	if err := s.MarkSeen(addr(1)); err != nil {
+		t.Fatalf("MarkSeen: %v", err)
+	}
+	if l, _ := s.GetLiveness(addr(1)); l.Failures != 0 {
+		t.Errorf("MarkSeen did not reset failures: %+v", l)
+	}
+}
+
+func testEvict(t *testing.T, s peer.Store) {
+	mustAdd(t, s, addr(1), nil)
+	mustAdd(t, s, addr(2), nil)
+	for i := 0; i < 3; i++ {
+		s.MarkFailed(addr(1))
+	}
+
+	evicted := s.Evict(peer.EvictionPolicy{MaxFailures: 3, TombstoneTTL: time.Minute})
+	if len(evicted) != 1 || evicted[0].Addr() != addr(1) {
+		t.Fatalf("Evict = %v, want %s", evicted, addr(1))
+	}
+	if ok, _ := s.Exists(addr(1)); ok {
+		t.Errorf("evicted peer still exists")
+	}
+	if !s.Tombstoned(addr(1)) {
+		t.Errorf("evicted peer is not tombstoned")
+	}
+	added, _ := s.AddPeers([]*peer.Peer{peer.NewPeer(addr(1), nil)}, true)
+	if len(added) != 0 {
+		t.Errorf("AddPeers re-added a tombstoned peer")
+	}
+	if err := s.AddPeer(peer.NewPeer(addr(1), nil)); err != peer.ErrPeerTombstoned {
+		t.Errorf("AddPeer tombstoned = %v, want %v", err, peer.ErrPeerTombstoned)
+	}
+	if _, err := s.PutRecord(peer.SignRecord(newIdentity(t), []string{addr(1)}, nil, 1)); err != peer.ErrPeerTombstoned {
+		t.Errorf("PutRecord tombstoned = %v, want %v", err, peer.ErrPeerTombstoned)
+	}
+	if err := s.ClearTombstone(addr(1)); err != nil {
+		t.Fatalf("ClearTombstone: %v", err)
+	}
+	if err := s.AddPeer(peer.NewPeer(addr(1), nil)); err != nil {
+		t.Errorf("AddPeer after ClearTombstone: %v", err)
+	}
+	s.RemovePeer(peer.NewPeer(addr(1), nil))
+
+	time.Sleep(20 * time.Millisecond)
+	evicted = s.Evict(peer.EvictionPolicy{MaxAge: 10 * time.Millisecond})
+	if len(evicted) != 1 || evicted[0].Addr() != addr(2) {
+		t.Errorf("Evict by age = %v, want %s", evicted, addr(2))
+	}
+}
+
+func testEvictPeer(t *testing.T, s peer.Store) {
+	if err := s.EvictPeer(addr(1), time.Minute); err != peer.ErrPeerNotFouund {
+		t.Errorf("EvictPeer missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	mustAdd(t, s, addr(1), nil)
+	if err := s.EvictPeer(addr(1), 10*time.Millisecond); err != nil {
+		t.Fatalf("EvictPeer: %v", err)
+	}
+	if !s.Tombstoned(addr(1)) {
+		t.Errorf("evicted peer is not tombstoned")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if s.Tombstoned(addr(1)) {
+		t.Errorf("tombstone did not expire")
+	}
+	added, _ := s.AddPeers([]*peer.Peer{peer.NewPeer(addr(1), nil)}, true)
+	if len(added) != 1 {
+		t.Errorf("AddPeers did not re-add a peer with an expired tombstone")
+	}
+}
+
+func testTransitions(t *testing.T, s peer.Store) {
+	if err := s.RecordTransition(addr(1), peer.Transition{}); err != peer.ErrPeerNotFouund {
+		t.Errorf("RecordTransition missing = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+
+	mustAdd(t, s, addr(1), nil)
+	states := []peer.PeerState{peer.Idle, peer.Connecting, peer.Ready, peer.TransientFailure}
+	for i := 0; i < 40; i++ {
+		err := s.RecordTransition(addr(1), peer.Transition{
+			From: states[i%len(states)],
+			To:   states[(i+1)%len(states)],
+			Time: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("RecordTransition: %v", err)
+		}
+	}
+
+	transitions, err := s.GetTransitions(addr(1))
+	if err != nil {
+		t.Fatalf("GetTransitions: %v", err)
+	}
+	if len(transitions) == 0 || len(transitions) >= 40 {
+		t.Errorf("GetTransitions returned %d transitions, want a bounded history", len(transitions))
+	}
+	last := transitions[len(transitions)-1]
+	if last.From != states[39%len(states)] || last.To != states[0] {
+		t.Errorf("last transition = %v, want the most recent one", last)
+	}
+
+	s.RemovePeer(peer.NewPeer(addr(1), nil))
+	if _, err := s.GetTransitions(addr(1)); err != peer.ErrPeerNotFouund {
+		t.Errorf("GetTransitions removed = %v, want %v", err, peer.ErrPeerNotFouund)
+	}
+}
+
+func testConcurrency(t *testing.T, s peer.Store) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				a := addr(i*100 + j)
+				s.AddPeer(peer.NewPeer(a, nil))
+				s.MarkSeen(a)
+				s.GetPeers()
+				s.MarkFailed(a)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(s.GetPeers()); n != 400 {
+		t.Errorf("GetPeers returned %d peers, want 400", n)
+	}
+}
+
+func addr(i int) string {
+	return fmt.Sprintf("127.0.0.1:%d", 10000+i)
+}
+
+func mustAdd(t *testing.T, s peer.Store, a string, attrs map[string]string) {
+	t.Helper()
+	if err := s.AddPeer(peer.NewPeer(a, attrs)); err != nil {
+		t.Fatalf("AddPeer(%s): %v", a, err)
+	}
+}
+
+func newIdentity(t *testing.T) *identity.Identity {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,119 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+var ErrWatchUnsupported = errors.New("peer: peer does not support watching peers")
+
+// WatchPeers sends the signed record of self to the peer and returns the stream of
+// the snapshot and the events of the peers known by the peer
+func (c *Client) WatchPeers(ctx context.Context, cc *grpc.ClientConn, self *Record) (p2p_pb.PeerService_WatchPeersClient, error) {
+	return p2p_pb.NewPeerServiceClient(cc).WatchPeers(ctx, &p2p_pb.WatchPeersRequest{
+		Self: RecordToPb(self),
+	})
+}
+
+// WatchNeighbors is like GetNeighbors but keeps receiving the peers of the neighbor
+// as they are added or updated. fn is called with the peers of every snapshot page
+// and event, the neighbor itself included in the first page. It blocks until ctx is
+// done or the watch fails and returns ErrWatchUnsupported if the neighbor does not
+// serve watches, in which case GetNeighbors must be polled instead
+func (ps *PeerService) WatchNeighbors(ctx context.Context, p *Peer, fn func([]*Peer)) error {
+	conn, err := ps.Connect(p.Addr())
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return errors.New("connection failed")
+	}
+	if conn.GetState() != connectivity.Ready {
+		return errors.New("connection not ready")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := ps.client.WatchPeers(ctx, conn, ps.Self().Record)
+	if err != nil {
+		return watchError(err)
+	}
+
+	// keep the links of the neighbor while the watch is quiet
+	go ps.refreshLinks(ctx, p.Addr())
+
+	var snapshot map[string]PeerState
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return watchError(err)
+		}
+		ps.peerstore.MarkSeen(p.Addr())
+
+		if e := res.Event; e != nil {
+			if e.Peer == nil {
+				continue
+			}
+			if e.Type == PeerRemoved.String() {
+				ps.views.apply(p.Addr(), res.Version, nil, []string{e.Peer.Address})
+			} else {
+				ps.views.apply(p.Addr(), res.Version, []*p2p_pb.Peer{e.Peer}, nil)
+				peers := peersFromPbPeers([]*p2p_pb.Peer{e.Peer})
+				ps.membership.relay(p.Addr(), peers)
+				fn(peers)
+			}
+			ps.routes.update(p.Addr(), ps.views.connected(p.Addr()), ps.clock.Now())
+			continue
+		}
+
+		// the first page of a snapshot carries the record of the neighbor
+		peers := peersFromPbPeers(res.Snapshot)
+		if res.Self != nil || snapshot == nil {
+			snapshot = make(map[string]PeerState)
+			if r := RecordFromPb(res.Self); r != nil && r.Verify() == nil {
+				peers = append(peers, NewPeerFromRecord(r))
+			}
+		}
+		for _, pb := range res.Snapshot {
+			snapshot[pb.Address] = PeerStateFromString(pb.State)
+		}
+		if res.Synced {
+			ps.views.reset(p.Addr(), res.Epoch, res.Version, snapshot)
+			ps.routes.update(p.Addr(), ps.views.connected(p.Addr()), ps.clock.Now())
+		}
+		if len(peers) > 0 {
+			ps.membership.relay(p.Addr(), peers)
+			fn(peers)
+		}
+	}
+}
+
+// refreshLinks updates the links of the neighbor at addr from its view until ctx is done
+func (ps *PeerService) refreshLinks(ctx context.Context, addr string) {
+	ticker := time.NewTicker(ps.routes.ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ps.routes.update(addr, ps.views.connected(addr), ps.clock.Now())
+		}
+	}
+}
+
+func watchError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return ErrWatchUnsupported
+	}
+	return err
+}
@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	PeerService_GetPeers_FullMethodName = "/p2p_proto.PeerService/GetPeers"
+	PeerService_GetPeers_FullMethodName   = "/p2p_proto.PeerService/GetPeers"
+	PeerService_WatchPeers_FullMethodName = "/p2p_proto.PeerService/WatchPeers"
 )
 
 // PeerServiceClient is the client API for PeerService service.
@@ -27,6 +28,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PeerServiceClient interface {
 	GetPeers(ctx context.Context, in *GetPeersRequest, opts ...grpc.CallOption) (*GetPeersResponse, error)
+	// WatchPeers sends a snapshot of the peers of the callee followed by the
+	// changes of its peerstore as they happen
+	WatchPeers(ctx context.Context, in *WatchPeersRequest, opts ...grpc.CallOption) (PeerService_WatchPeersClient, error)
 }
 
 type peerServiceClient struct {
@@ -46,11 +50,46 @@ func (c *peerServiceClient) GetPeers(ctx context.Context, in *GetPeersRequest, o
 	return out, nil
 }
 
+func (c *peerServiceClient) WatchPeers(ctx context.Context, in *WatchPeersRequest, opts ...grpc.CallOption) (PeerService_WatchPeersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PeerService_ServiceDesc.Streams[0], PeerService_WatchPeers_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerServiceWatchPeersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PeerService_WatchPeersClient interface {
+	Recv() (*WatchPeersResponse, error)
+	grpc.ClientStream
+}
+
+type peerServiceWatchPeersClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerServiceWatchPeersClient) Recv() (*WatchPeersResponse, error) {
+	m := new(WatchPeersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // PeerServiceServer is the server API for PeerService service.
 // All implementations must embed UnimplementedPeerServiceServer
 // for forward compatibility
 type PeerServiceServer interface {
 	GetPeers(context.Context, *GetPeersRequest) (*GetPeersResponse, error)
+	// WatchPeers sends a snapshot of the peers of the callee followed by the
+	// changes of its peerstore as they happen
+	WatchPeers(*WatchPeersRequest, PeerService_WatchPeersServer) error
 	mustEmbedUnimplementedPeerServiceServer()
 }
 
@@ -61,6 +100,9 @@ type UnimplementedPeerServiceServer struct {
 func (UnimplementedPeerServiceServer) GetPeers(context.Context, *GetPeersRequest) (*GetPeersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPeers not implemented")
 }
+func (UnimplementedPeerServiceServer) WatchPeers(*WatchPeersRequest, PeerService_WatchPeersServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPeers not implemented")
+}
 func (UnimplementedPeerServiceServer) mustEmbedUnimplementedPeerServiceServer() {}
 
 // UnsafePeerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -92,6 +134,27 @@ func _PeerService_GetPeers_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PeerService_WatchPeers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPeersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServiceServer).WatchPeers(m, &peerServiceWatchPeersServer{stream})
+}
+
+type PeerService_WatchPeersServer interface {
+	Send(*WatchPeersResponse) error
+	grpc.ServerStream
+}
+
+type peerServiceWatchPeersServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerServiceWatchPeersServer) Send(m *WatchPeersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // PeerService_ServiceDesc is the grpc.ServiceDesc for PeerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -104,6 +167,622 @@ var PeerService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _PeerService_GetPeers_Handler,
 		},
 	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPeers",
+			Handler:       _PeerService_WatchPeers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "p2p.proto",
+}
+
+const (
+	Membership_Ping_FullMethodName    = "/p2p_proto.Membership/Ping"
+	Membership_PingReq_FullMethodName = "/p2p_proto.Membership/PingReq"
+)
+
+// MembershipClient is the client API for Membership service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MembershipClient interface {
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error)
+}
+
+type membershipClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMembershipClient(cc grpc.ClientConnInterface) MembershipClient {
+	return &membershipClient{cc}
+}
+
+func (c *membershipClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, Membership_Ping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *membershipClient) PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, Membership_PingReq_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MembershipServer is the server API for Membership service.
+// All implementations must embed UnimplementedMembershipServer
+// for forward compatibility
+type MembershipServer interface {
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	PingReq(context.Context, *PingReqRequest) (*PingResponse, error)
+	mustEmbedUnimplementedMembershipServer()
+}
+
+// UnimplementedMembershipServer must be embedded to have forward compatible implementations.
+type UnimplementedMembershipServer struct {
+}
+
+func (UnimplementedMembershipServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedMembershipServer) PingReq(context.Context, *PingReqRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingReq not implemented")
+}
+func (UnimplementedMembershipServer) mustEmbedUnimplementedMembershipServer() {}
+
+// UnsafeMembershipServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MembershipServer will
+// result in compilation errors.
+type UnsafeMembershipServer interface {
+	mustEmbedUnimplementedMembershipServer()
+}
+
+func RegisterMembershipServer(s grpc.ServiceRegistrar, srv MembershipServer) {
+	s.RegisterService(&Membership_ServiceDesc, srv)
+}
+
+func _Membership_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MembershipServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Membership_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MembershipServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Membership_PingReq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingReqRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MembershipServer).PingReq(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Membership_PingReq_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MembershipServer).PingReq(ctx, req.(*PingReqRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Membership_ServiceDesc is the grpc.ServiceDesc for Membership service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Membership_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "p2p_proto.Membership",
+	HandlerType: (*MembershipServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Membership_Ping_Handler,
+		},
+		{
+			MethodName: "PingReq",
+			Handler:    _Membership_PingReq_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "p2p.proto",
+}
+
+const (
+	Pubsub_Connect_FullMethodName = "/p2p_proto.Pubsub/Connect"
+)
+
+// PubsubClient is the client API for Pubsub service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PubsubClient interface {
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Pubsub_ConnectClient, error)
+}
+
+type pubsubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPubsubClient(cc grpc.ClientConnInterface) PubsubClient {
+	return &pubsubClient{cc}
+}
+
+func (c *pubsubClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Pubsub_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Pubsub_ServiceDesc.Streams[0], Pubsub_Connect_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pubsubConnectClient{stream}
+	return x, nil
+}
+
+type Pubsub_ConnectClient interface {
+	Send(*PubsubFrame) error
+	Recv() (*PubsubFrame, error)
+	grpc.ClientStream
+}
+
+type pubsubConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *pubsubConnectClient) Send(m *PubsubFrame) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pubsubConnectClient) Recv() (*PubsubFrame, error) {
+	m := new(PubsubFrame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PubsubServer is the server API for Pubsub service.
+// All implementations must embed UnimplementedPubsubServer
+// for forward compatibility
+type PubsubServer interface {
+	Connect(Pubsub_ConnectServer) error
+	mustEmbedUnimplementedPubsubServer()
+}
+
+// UnimplementedPubsubServer must be embedded to have forward compatible implementations.
+type UnimplementedPubsubServer struct {
+}
+
+func (UnimplementedPubsubServer) Connect(Pubsub_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedPubsubServer) mustEmbedUnimplementedPubsubServer() {}
+
+// UnsafePubsubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PubsubServer will
+// result in compilation errors.
+type UnsafePubsubServer interface {
+	mustEmbedUnimplementedPubsubServer()
+}
+
+func RegisterPubsubServer(s grpc.ServiceRegistrar, srv PubsubServer) {
+	s.RegisterService(&Pubsub_ServiceDesc, srv)
+}
+
+func _Pubsub_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PubsubServer).Connect(&pubsubConnectServer{stream})
+}
+
+type Pubsub_ConnectServer interface {
+	Send(*PubsubFrame) error
+	Recv() (*PubsubFrame, error)
+	grpc.ServerStream
+}
+
+type pubsubConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *pubsubConnectServer) Send(m *PubsubFrame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pubsubConnectServer) Recv() (*PubsubFrame, error) {
+	m := new(PubsubFrame)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Pubsub_ServiceDesc is the grpc.ServiceDesc for Pubsub service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Pubsub_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "p2p_proto.Pubsub",
+	HandlerType: (*PubsubServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Pubsub_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "p2p.proto",
+}
+
+const (
+	Messaging_Send_FullMethodName   = "/p2p_proto.Messaging/Send"
+	Messaging_Stream_FullMethodName = "/p2p_proto.Messaging/Stream"
+	Messaging_Relay_FullMethodName  = "/p2p_proto.Messaging/Relay"
+)
+
+// MessagingClient is the client API for Messaging service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MessagingClient interface {
+	Send(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error)
+	Stream(ctx context.Context, opts ...grpc.CallOption) (Messaging_StreamClient, error)
+	Relay(ctx context.Context, in *RelayRequest, opts ...grpc.CallOption) (*Envelope, error)
+}
+
+type messagingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessagingClient(cc grpc.ClientConnInterface) MessagingClient {
+	return &messagingClient{cc}
+}
+
+func (c *messagingClient) Send(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error) {
+	out := new(Envelope)
+	err := c.cc.Invoke(ctx, Messaging_Send_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Messaging_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Messaging_ServiceDesc.Streams[0], Messaging_Stream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &messagingStreamClient{stream}
+	return x, nil
+}
+
+type Messaging_StreamClient interface {
+	Send(*Envelope) error
+	Recv() (*Envelope, error)
+	grpc.ClientStream
+}
+
+type messagingStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *messagingStreamClient) Send(m *Envelope) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *messagingStreamClient) Recv() (*Envelope, error) {
+	m := new(Envelope)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *messagingClient) Relay(ctx context.Context, in *RelayRequest, opts ...grpc.CallOption) (*Envelope, error) {
+	out := new(Envelope)
+	err := c.cc.Invoke(ctx, Messaging_Relay_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MessagingServer is the server API for Messaging service.
+// All implementations must embed UnimplementedMessagingServer
+// for forward compatibility
+type MessagingServer interface {
+	Send(context.Context, *Envelope) (*Envelope, error)
+	Stream(Messaging_StreamServer) error
+	Relay(context.Context, *RelayRequest) (*Envelope, error)
+	mustEmbedUnimplementedMessagingServer()
+}
+
+// UnimplementedMessagingServer must be embedded to have forward compatible implementations.
+type UnimplementedMessagingServer struct {
+}
+
+func (UnimplementedMessagingServer) Send(context.Context, *Envelope) (*Envelope, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedMessagingServer) Stream(Messaging_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedMessagingServer) Relay(context.Context, *RelayRequest) (*Envelope, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Relay not implemented")
+}
+func (UnimplementedMessagingServer) mustEmbedUnimplementedMessagingServer() {}
+
+// UnsafeMessagingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessagingServer will
+// result in compilation errors.
+type UnsafeMessagingServer interface {
+	mustEmbedUnimplementedMessagingServer()
+}
+
+func RegisterMessagingServer(s grpc.ServiceRegistrar, srv MessagingServer) {
+	s.RegisterService(&Messaging_ServiceDesc, srv)
+}
+
+func _Messaging_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Messaging_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServer).Send(ctx, req.(*Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Messaging_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MessagingServer).Stream(&messagingStreamServer{stream})
+}
+
+type Messaging_StreamServer interface {
+	Send(*Envelope) error
+	Recv() (*Envelope, error)
+	grpc.ServerStream
+}
+
+type messagingStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *messagingStreamServer) Send(m *Envelope) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *messagingStreamServer) Recv() (*Envelope, error) {
+	m := new(Envelope)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Messaging_Relay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServer).Relay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Messaging_Relay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServer).Relay(ctx, req.(*RelayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Messaging_ServiceDesc is the grpc.ServiceDesc for Messaging service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Messaging_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "p2p_proto.Messaging",
+	HandlerType: (*MessagingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    _Messaging_Send_Handler,
+		},
+		{
+			MethodName: "Relay",
+			Handler:    _Messaging_Relay_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Messaging_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "p2p.proto",
+}
+
+const (
+	Kademlia_FindNode_FullMethodName  = "/p2p_proto.Kademlia/FindNode"
+	Kademlia_FindValue_FullMethodName = "/p2p_proto.Kademlia/FindValue"
+	Kademlia_Put_FullMethodName       = "/p2p_proto.Kademlia/Put"
+)
+
+// KademliaClient is the client API for Kademlia service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KademliaClient interface {
+	FindNode(ctx context.Context, in *FindNodeRequest, opts ...grpc.CallOption) (*FindNodeResponse, error)
+	FindValue(ctx context.Context, in *FindValueRequest, opts ...grpc.CallOption) (*FindValueResponse, error)
+	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error)
+}
+
+type kademliaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKademliaClient(cc grpc.ClientConnInterface) KademliaClient {
+	return &kademliaClient{cc}
+}
+
+func (c *kademliaClient) FindNode(ctx context.Context, in *FindNodeRequest, opts ...grpc.CallOption) (*FindNodeResponse, error) {
+	out := new(FindNodeResponse)
+	err := c.cc.Invoke(ctx, Kademlia_FindNode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kademliaClient) FindValue(ctx context.Context, in *FindValueRequest, opts ...grpc.CallOption) (*FindValueResponse, error) {
+	out := new(FindValueResponse)
+	err := c.cc.Invoke(ctx, Kademlia_FindValue_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kademliaClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error) {
+	out := new(PutResponse)
+	err := c.cc.Invoke(ctx, Kademlia_Put_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KademliaServer is the server API for Kademlia service.
+// All implementations must embed UnimplementedKademliaServer
+// for forward compatibility
+type KademliaServer interface {
+	FindNode(context.Context, *FindNodeRequest) (*FindNodeResponse, error)
+	FindValue(context.Context, *FindValueRequest) (*FindValueResponse, error)
+	Put(context.Context, *PutRequest) (*PutResponse, error)
+	mustEmbedUnimplementedKademliaServer()
+}
+
+// UnimplementedKademliaServer must be embedded to have forward compatible implementations.
+type UnimplementedKademliaServer struct {
+}
+
+func (UnimplementedKademliaServer) FindNode(context.Context, *FindNodeRequest) (*FindNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindNode not implemented")
+}
+func (UnimplementedKademliaServer) FindValue(context.Context, *FindValueRequest) (*FindValueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindValue not implemented")
+}
+func (UnimplementedKademliaServer) Put(context.Context, *PutRequest) (*PutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Put not implemented")
+}
+func (UnimplementedKademliaServer) mustEmbedUnimplementedKademliaServer() {}
+
+// UnsafeKademliaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KademliaServer will
+// result in compilation errors.
+type UnsafeKademliaServer interface {
+	mustEmbedUnimplementedKademliaServer()
+}
+
+func RegisterKademliaServer(s grpc.ServiceRegistrar, srv KademliaServer) {
+	s.RegisterService(&Kademlia_ServiceDesc, srv)
+}
+
+func _Kademlia_FindNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KademliaServer).FindNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kademlia_FindNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KademliaServer).FindNode(ctx, req.(*FindNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kademlia_FindValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KademliaServer).FindValue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kademlia_FindValue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KademliaServer).FindValue(ctx, req.(*FindValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kademlia_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KademliaServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kademlia_Put_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KademliaServer).Put(ctx, req.(*PutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Kademlia_ServiceDesc is the grpc.ServiceDesc for Kademlia service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kademlia_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "p2p_proto.Kademlia",
+	HandlerType: (*KademliaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FindNode",
+			Handler:    _Kademlia_FindNode_Handler,
+		},
+		{
+			MethodName: "FindValue",
+			Handler:    _Kademlia_FindValue_Handler,
+		},
+		{
+			MethodName: "Put",
+			Handler:    _Kademlia_Put_Handler,
+		},
+	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "p2p.proto",
 }
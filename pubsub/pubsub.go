@@ -0,0 +1,352 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/clock"
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultDegree            = 6
+	defaultDegreeLow         = 4
+	defaultDegreeHigh        = 12
+	defaultHeartbeatInterval = 1 * time.Second
+	defaultSeenTTL           = 2 * time.Minute
+
+	// subscriptionBuffer is the number of messages buffered per subscription.
+	// Messages are dropped for subscribers which do not keep up
+	subscriptionBuffer = 64
+	// streamBuffer is the number of frames queued per peer stream
+	streamBuffer = 256
+)
+
+var (
+	ErrEmptyTopic    = errors.New("pubsub: empty topic")
+	ErrMissingRecord = errors.New("pubsub: stream opened without peer record")
+	ErrStreamExists  = errors.New("pubsub: peer already has a stream")
+)
+
+// Message is a message published on a topic
+type Message struct {
+	ID    string
+	Topic string
+	Data  []byte
+	// From is the ID of the publisher as claimed by the message. Messages are not signed,
+	// so it is advisory only: any peer forwarding the message can set it
+	From string
+	// ReceivedFrom is the address of the peer the message was received from.
+	// It is empty for messages published by the local node
+	ReceivedFrom string
+}
+
+type subscription struct {
+	ch chan *Message
+}
+
+// Pubsub delivers messages published on a topic to the subscribers of the topic
+// on every node. The peers subscribed to a topic form a mesh of bounded degree and
+// messages are forwarded along the mesh only, so a message reaches every subscriber
+// without being sent to every peer. Message IDs are remembered to drop duplicates
+type Pubsub struct {
+	cfg   config.Pubsub
+	ps    *peer.PeerService
+	clock clock.Clock
+
+	lock sync.Mutex
+	// subs are the local subscriptions per topic
+	subs map[string]map[*subscription]bool
+	// meshes are the mesh peers of the topics subscribed locally
+	meshes  map[string]map[string]bool
+	streams map[string]*stream
+	seen    *seenCache
+	seq     uint64
+	// done is closed once Start returns to end the streams opened by peers
+	done chan struct{}
+
+	logger zerolog.Logger
+
+	p2p_pb.UnimplementedPubsubServer
+}
+
+// NewPubsub creates a new pubsub. Zero config values are replaced by defaults
+func NewPubsub(cfg config.Pubsub, ps *peer.PeerService, logger zerolog.Logger) *Pubsub {
+	if cfg.Degree <= 0 {
+		cfg.Degree = defaultDegree
+	}
+	if cfg.DegreeLow <= 0 || cfg.DegreeLow > cfg.Degree {
+		cfg.DegreeLow = min(defaultDegreeLow, cfg.Degree)
+	}
+	if cfg.DegreeHigh < cfg.Degree {
+		cfg.DegreeHigh = max(defaultDegreeHigh, cfg.Degree)
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.SeenTTL <= 0 {
+		cfg.SeenTTL = defaultSeenTTL
+	}
+
+	c := ps.Clock()
+	return &Pubsub{
+		cfg:     cfg,
+		ps:      ps,
+		clock:   c,
+		subs:    make(map[string]map[*subscription]bool),
+		meshes:  make(map[string]map[string]bool),
+		streams: make(map[string]*stream),
+		seen:    newSeenCache(cfg.SeenTTL),
+		// start the sequence from the clock so that IDs are not reused across restarts
+		seq:    uint64(c.Now().UnixNano()),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+}
+
+// RegisterService registers the pubsub rpc service to the server
+func (p *Pubsub) RegisterService(srv grpc.ServiceRegistrar) {
+	p2p_pb.RegisterPubsubServer(srv, p)
+}
+
+// Start opens streams to the connected peers and maintains the topic meshes
+// every heartbeat until ctx is done. The streams opened by peers are ended once
+// Start returns so that they do not hold up the graceful stop of the server
+func (p *Pubsub) Start(ctx context.Context) error {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	p.connect(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.connect(ctx)
+			p.heartbeat()
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the messages published on topic until
+// ctx is done. The channel is closed once the subscription is cancelled
+func (p *Pubsub) Subscribe(ctx context.Context, topic string) (<-chan *Message, error) {
+	if topic == "" {
+		return nil, ErrEmptyTopic
+	}
+
+	sub := &subscription{ch: make(chan *Message, subscriptionBuffer)}
+
+	p.lock.Lock()
+	subs, ok := p.subs[topic]
+	if !ok {
+		subs = make(map[*subscription]bool)
+		p.subs[topic] = subs
+		p.meshes[topic] = make(map[string]bool)
+		p.broadcastLocked(&p2p_pb.PubsubControl{Subscribe: []string{topic}})
+	}
+	subs[sub] = true
+	p.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.unsubscribe(topic, sub)
+	}()
+	return sub.ch, nil
+}
+
+func (p *Pubsub) unsubscribe(topic string, sub *subscription) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	subs := p.subs[topic]
+	delete(subs, sub)
+	close(sub.ch)
+	if len(subs) > 0 {
+		return
+	}
+
+	// leave the mesh of the topic
+	for addr := range p.meshes[topic] {
+		if st, ok := p.streams[addr]; ok {
+			st.send(&p2p_pb.PubsubFrame{Control: &p2p_pb.PubsubControl{Prune: []string{topic}}})
+		}
+	}
+	delete(p.subs, topic)
+	delete(p.meshes, topic)
+	p.broadcastLocked(&p2p_pb.PubsubControl{Unsubscribe: []string{topic}})
+}
+
+// Publish publishes data on topic. The message is delivered to the local subscribers
+// and forwarded to the peers asynchronously
+func (p *Pubsub) Publish(ctx context.Context, topic string, data []byte) error {
+	if topic == "" {
+		return ErrEmptyTopic
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.seq++
+	from := p.ps.Identity().ID()
+	pb := &p2p_pb.PubsubMessage{
+		ID:    from + ":" + strconv.FormatUint(p.seq, 16),
+		Topic: topic,
+		Data:  data,
+		From:  from,
+	}
+	p.seen.add(pb.ID, p.clock.Now())
+	p.deliverLocked(messageFromPb(pb))
+	p.forwardLocked(pb, "")
+	return nil
+}
+
+// Topics returns the topics subscribed locally
+func (p *Pubsub) Topics() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var topics []string
+	for topic := range p.subs {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Mesh returns the addresses of the mesh peers of topic
+func (p *Pubsub) Mesh(topic string) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var addrs []string
+	for addr := range p.meshes[topic] {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// deliverLocked delivers the message to the local subscribers of its topic
+func (p *Pubsub) deliverLocked(msg *Message) {
+	for sub := range p.subs[msg.Topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			p.logger.Debug().Msgf("pubsub: dropped message %s for slow subscriber of %s", msg.ID, msg.Topic)
+		}
+	}
+}
+
+// forwardLocked sends the message to the mesh peers of its topic except the peer it
+// was received from. If the topic is not subscribed locally, or its mesh is not built
+// yet, the message is sent to up to Degree peers subscribed to the topic
+func (p *Pubsub) forwardLocked(pb *p2p_pb.PubsubMessage, from string) {
+	targets := p.meshes[pb.Topic]
+	if len(targets) == 0 {
+		targets = make(map[string]bool)
+		for _, addr := range p.subscribersLocked(pb.Topic, nil, p.cfg.Degree) {
+			targets[addr] = true
+		}
+	}
+
+	frame := &p2p_pb.PubsubFrame{Messages: []*p2p_pb.PubsubMessage{pb}}
+	for addr := range targets {
+		if addr == from {
+			continue
+		}
+		if st, ok := p.streams[addr]; ok {
+			st.send(frame)
+		}
+	}
+}
+
+// subscribersLocked returns up to n random peers subscribed to topic excluding the given peers
+func (p *Pubsub) subscribersLocked(topic string, exclude map[string]bool, n int) []string {
+	var addrs []string
+	for addr, st := range p.streams {
+		if st.topics[topic] && !exclude[addr] {
+			addrs = append(addrs, addr)
+		}
+	}
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	if len(addrs) > n {
+		addrs = addrs[:n]
+	}
+	return addrs
+}
+
+// broadcastLocked sends the control message to every peer
+func (p *Pubsub) broadcastLocked(c *p2p_pb.PubsubControl) {
+	frame := &p2p_pb.PubsubFrame{Control: c}
+	for _, st := range p.streams {
+		st.send(frame)
+	}
+}
+
+// connect opens streams to the connected peers without one
+func (p *Pubsub) connect(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, pr := range p.ps.GetPeers() {
+		if _, ok := p.streams[pr.Addr()]; ok || !ready(pr) {
+			continue
+		}
+		st := newStream(pr.Addr(), false)
+		p.streams[st.addr] = st
+		go p.dial(ctx, st)
+	}
+}
+
+// heartbeat grafts peers into the meshes smaller than DegreeLow, prunes peers from
+// the meshes larger than DegreeHigh and forgets expired message IDs
+func (p *Pubsub) heartbeat() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for topic, mesh := range p.meshes {
+		// drop the peers which unsubscribed or whose stream ended
+		for addr := range mesh {
+			if st, ok := p.streams[addr]; !ok || !st.topics[topic] {
+				delete(mesh, addr)
+			}
+		}
+
+		if len(mesh) < p.cfg.DegreeLow {
+			for _, addr := range p.subscribersLocked(topic, mesh, p.cfg.Degree-len(mesh)) {
+				mesh[addr] = true
+				p.streams[addr].send(&p2p_pb.PubsubFrame{Control: &p2p_pb.PubsubControl{Graft: []string{topic}}})
+			}
+		}
+
+		if len(mesh) > p.cfg.DegreeHigh {
+			var addrs []string
+			for addr := range mesh {
+				addrs = append(addrs, addr)
+			}
+			rand.Shuffle(len(addrs), func(i, j int) {
+				addrs[i], addrs[j] = addrs[j], addrs[i]
+			})
+			for _, addr := range addrs[p.cfg.Degree:] {
+				delete(mesh, addr)
+				p.streams[addr].send(&p2p_pb.PubsubFrame{Control: &p2p_pb.PubsubControl{Prune: []string{topic}}})
+			}
+		}
+	}
+
+	p.seen.expire(p.clock.Now())
+}
@@ -0,0 +1,34 @@
+package pubsub
+
+import "time"
+
+// seenCache remembers the IDs of the messages received recently to drop duplicates
+type seenCache struct {
+	ttl time.Duration
+	ids map[string]time.Time
+}
+
+func newSeenCache(ttl time.Duration) *seenCache {
+	return &seenCache{
+		ttl: ttl,
+		ids: make(map[string]time.Time),
+	}
+}
+
+// add records the message ID and returns false if it was already seen
+func (c *seenCache) add(id string, now time.Time) bool {
+	if expiry, ok := c.ids[id]; ok && now.Before(expiry) {
+		return false
+	}
+	c.ids[id] = now.Add(c.ttl)
+	return true
+}
+
+// expire forgets the message IDs older than the TTL
+func (c *seenCache) expire(now time.Time) {
+	for id, expiry := range c.ids {
+		if !now.Before(expiry) {
+			delete(c.ids, id)
+		}
+	}
+}
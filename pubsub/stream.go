@@ -0,0 +1,258 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"github.com/mr-shifu/grpc-p2p/transport"
+)
+
+// stream is the bidirectional stream to a peer. Frames are queued and written by a
+// single goroutine; frames are dropped if the peer does not keep up
+type stream struct {
+	addr string
+	out  chan *p2p_pb.PubsubFrame
+	done chan struct{}
+	// stop is closed to end the stream once it is replaced
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// inbound is true for the streams opened by the peer
+	inbound bool
+	// established is true once a frame was received, guarded by the lock of Pubsub
+	established bool
+	// topics subscribed by the peer, guarded by the lock of Pubsub
+	topics map[string]bool
+}
+
+func newStream(addr string, inbound bool) *stream {
+	return &stream{
+		addr:        addr,
+		out:         make(chan *p2p_pb.PubsubFrame, streamBuffer),
+		done:        make(chan struct{}),
+		stop:        make(chan struct{}),
+		inbound:     inbound,
+		established: inbound,
+		topics:      make(map[string]bool),
+	}
+}
+
+// close ends the stream
+func (s *stream) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// send queues the frame without blocking and returns false if it was dropped
+func (s *stream) send(f *p2p_pb.PubsubFrame) bool {
+	select {
+	case s.out <- f:
+		return true
+	default:
+		return false
+	}
+}
+
+// frameStream is implemented by both sides of the Connect stream
+type frameStream interface {
+	Send(*p2p_pb.PubsubFrame) error
+	Recv() (*p2p_pb.PubsubFrame, error)
+}
+
+// Connect handles a stream opened by a peer. The first frame carries the signed record
+// of the peer and the stream is keyed by the verified address of the record. Records
+// can be replayed, so the stream is rejected with ErrStreamExists if the peer already
+// has a stream, unless the caller presented a certificate of the address
+func (p *Pubsub) Connect(srv p2p_pb.Pubsub_ConnectServer) error {
+	hello, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	if hello.Self == nil {
+		return ErrMissingRecord
+	}
+	record, err := p.ps.AdmitCaller(srv.Context(), hello.Self)
+	if err != nil {
+		return err
+	}
+
+	st := newStream(record.Addrs[0], true)
+	_, certified := transport.PeerCertificate(srv.Context())
+	if err := p.register(st, certified); err != nil {
+		return err
+	}
+	st.send(p.hello())
+	p.handle(st, hello)
+
+	return p.run(srv.Context(), st, srv)
+}
+
+// dial opens a stream to the peer and runs it until the stream ends
+func (p *Pubsub) dial(ctx context.Context, st *stream) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	client := p2p_pb.NewPubsubClient(p.ps.Client(st.addr))
+	cs, err := client.Connect(ctx)
+	if err != nil {
+		p.unregister(st)
+		return
+	}
+	st.send(p.hello())
+
+	p.run(ctx, st, cs)
+}
+
+// run writes the queued frames and handles the received frames until the stream
+// ends, ctx is done or the pubsub is stopped
+func (p *Pubsub) run(ctx context.Context, st *stream, fs frameStream) error {
+	defer p.unregister(st)
+	defer close(st.done)
+
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			f, err := fs.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			p.handle(st, f)
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case f := <-st.out:
+				if err := fs.Send(f); err != nil {
+					return
+				}
+			case <-st.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return nil
+	case <-st.stop:
+		return nil
+	}
+}
+
+// hello returns the first frame sent on a stream announcing the local subscriptions
+func (p *Pubsub) hello() *p2p_pb.PubsubFrame {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var topics []string
+	for topic := range p.subs {
+		topics = append(topics, topic)
+	}
+	return &p2p_pb.PubsubFrame{
+		Self:    peer.RecordToPb(p.ps.Self().Record),
+		Control: &p2p_pb.PubsubControl{Subscribe: topics},
+	}
+}
+
+// register tracks the stream opened by a peer as the stream to the peer. A certified
+// stream replaces the previous stream of the peer, which is closed. Otherwise the
+// previous stream is only replaced if it is a dial of the local node not answered
+// yet, and only by the peer with the lower address, so that the peers dialing each
+// other at the same time keep the same stream
+func (p *Pubsub) register(st *stream, certified bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if old, ok := p.streams[st.addr]; ok {
+		pending := !old.inbound && !old.established
+		if !certified && !(pending && st.addr < p.ps.Self().Addr()) {
+			return ErrStreamExists
+		}
+		old.close()
+	}
+	p.streams[st.addr] = st
+	return nil
+}
+
+// unregister stops tracking the stream and removes its peer from the meshes
+func (p *Pubsub) unregister(st *stream) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.streams[st.addr] != st {
+		return
+	}
+	delete(p.streams, st.addr)
+	for _, mesh := range p.meshes {
+		delete(mesh, st.addr)
+	}
+}
+
+// handle applies the control messages of the frame and delivers and forwards its messages
+func (p *Pubsub) handle(st *stream, f *p2p_pb.PubsubFrame) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	st.established = true
+
+	if c := f.Control; c != nil {
+		for _, topic := range c.Subscribe {
+			st.topics[topic] = true
+		}
+		for _, topic := range c.Unsubscribe {
+			delete(st.topics, topic)
+			delete(p.meshes[topic], st.addr)
+		}
+		var prune []string
+		for _, topic := range c.Graft {
+			mesh, ok := p.meshes[topic]
+			if !ok {
+				// not subscribed to the topic
+				prune = append(prune, topic)
+				continue
+			}
+			mesh[st.addr] = true
+		}
+		for _, topic := range c.Prune {
+			delete(p.meshes[topic], st.addr)
+		}
+		if len(prune) > 0 {
+			st.send(&p2p_pb.PubsubFrame{Control: &p2p_pb.PubsubControl{Prune: prune}})
+		}
+	}
+
+	now := p.clock.Now()
+	for _, pb := range f.Messages {
+		if !p.seen.add(pb.ID, now) {
+			continue
+		}
+		msg := messageFromPb(pb)
+		msg.ReceivedFrom = st.addr
+		p.deliverLocked(msg)
+		p.forwardLocked(pb, st.addr)
+	}
+}
+
+func messageFromPb(pb *p2p_pb.PubsubMessage) *Message {
+	return &Message{
+		ID:    pb.ID,
+		Topic: pb.Topic,
+		Data:  pb.Data,
+		From:  pb.From,
+	}
+}
+
+// ready returns true if the peer can be dialed without waiting
+func ready(p *peer.Peer) bool {
+	return p.GetState() == peer.Ready
+}
@@ -0,0 +1,110 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+)
+
+// configPollInterval is the period of checking the config file for changes
+const configPollInterval = 2 * time.Second
+
+// reloadableKeys are the config keys applied without restarting the node
+var reloadableKeys = map[string]bool{
+	"local.attributes": true,
+	"bootstrap":        true,
+}
+
+// Reload loads the config file again and applies the changes to the running node.
+// New bootstrap peers are added to the peerstore and the record of self is re-signed
+// if the local attributes changed. Changes of any other setting are rejected with
+// ErrConfigRestartRequired and nothing is applied. A node created without a config
+// file returns ErrNoConfigFile
+func (n *Node) Reload() error {
+	if n.cfgpath == "" {
+		return ErrNoConfigFile
+	}
+	cfg, _, err := config.NewLoader().WithFile(n.cfgpath).Load()
+	if err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	changed := config.Diff(n.cfg, cfg)
+	var restart []string
+	for _, key := range changed {
+		if !reloadableKeys[key] {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		return fmt.Errorf("%w: %s", ErrConfigRestartRequired, strings.Join(restart, ", "))
+	}
+
+	for _, key := range changed {
+		switch key {
+		case "local.attributes":
+			n.peerService.SetAttributes(cfg.Local.Attributes)
+			n.logger.Info().Msg("local attributes updated")
+		case "bootstrap":
+			added := n.peerService.SetBootstrap(cfg.Bootstrap)
+			n.logger.Info().Int("added", len(added)).Msg("bootstrap peers updated")
+		}
+	}
+
+	n.cfg = cfg
+	n.local = &cfg.Local
+	return nil
+}
+
+// watchConfig reloads the config when the config file changes or the process
+// receives SIGHUP, until ctx is done
+func (n *Node) watchConfig(ctx context.Context) error {
+	if n.cfgpath == "" {
+		return nil
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	last := fileVersion(n.cfgpath)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			last = fileVersion(n.cfgpath)
+		case <-ticker.C:
+			v := fileVersion(n.cfgpath)
+			if v == last {
+				continue
+			}
+			last = v
+		}
+
+		if err := n.Reload(); err != nil {
+			n.logger.Error().Err(err).Msg("failed to reload config")
+		}
+	}
+}
+
+// fileVersion identifies the content of a file by its modification time and size
+func fileVersion(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,83 @@
+package p2p
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	"github.com/rs/zerolog"
+)
+
+const reloadBase = `
+local:
+  addr: 127.0.0.1:9001
+  name: node
+  clusterName: cluster
+`
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(reloadBase)
+	n, err := NewNode(path, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := n.PeerService().Self().Record.Seq
+
+	// attributes and bootstrap peers are applied to the running node
+	write(reloadBase + `  attributes:
+    zone: a
+bootstrap:
+  - addr: 127.0.0.1:9002
+    name: peer
+    clusterName: cluster
+`)
+	if err := n.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	self := n.PeerService().Self()
+	if self.Attributes()["zone"] != "a" {
+		t.Errorf("attributes = %v, want zone=a", self.Attributes())
+	}
+	if self.Record.Seq <= seq {
+		t.Errorf("record of self not re-signed: seq %d, was %d", self.Record.Seq, seq)
+	}
+	if got := len(n.PeerService().GetPeers()); got != 1 {
+		t.Errorf("peerstore has %d peers, want 1", got)
+	}
+
+	// other settings require a restart and are not applied
+	write(`
+local:
+  addr: 127.0.0.1:9003
+  name: node
+  clusterName: cluster
+`)
+	if err := n.Reload(); !errors.Is(err, ErrConfigRestartRequired) {
+		t.Errorf("Reload() = %v, want %v", err, ErrConfigRestartRequired)
+	}
+	if n.cfg.Local.Addr != "127.0.0.1:9001" {
+		t.Errorf("local.addr = %s after a rejected reload", n.cfg.Local.Addr)
+	}
+}
+
+func TestReloadWithoutConfigFile(t *testing.T) {
+	n, err := NewNodeFromConfig(&config.Config{
+		Local: config.Peer{Addr: "127.0.0.1:9001", Name: "node", ClusterName: "cluster"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Reload(); !errors.Is(err, ErrNoConfigFile) {
+		t.Errorf("Reload() = %v, want %v", err, ErrNoConfigFile)
+	}
+}
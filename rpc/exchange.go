@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"sort"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultPageSize        = 256
+	defaultMaxResponseSize = 1 << 20
+)
+
+// page counts the items of a response within the page size and response size limits
+type page struct {
+	items int
+	size  int
+	limit int
+	max   int
+}
+
+// newPage returns a page for a response of size bytes without items
+func (r *RpcService) newPage(size int) *page {
+	return &page{
+		size:  size,
+		limit: r.cfg.PageSize,
+		max:   r.cfg.MaxResponseSize,
+	}
+}
+
+// add adds an item of n bytes encoded in field num of the response and reports
+// whether it fits in the page. A page always holds at least one item
+func (p *page) add(num protowire.Number, n int) bool {
+	n += protowire.SizeTag(num) + protowire.SizeVarint(uint64(n))
+	if p.items > 0 && (p.items >= p.limit || p.size+n > p.max) {
+		return false
+	}
+	p.items++
+	p.size += n
+	return true
+}
+
+// deltas returns the peers changed since the version of the request or false if
+// the changes are no longer known and a snapshot must be sent instead
+func (r *RpcService) deltas(req *p2p_pb.GetPeersRequest, res *p2p_pb.GetPeersResponse) bool {
+	if req.Epoch != res.Epoch || req.Cursor != "" {
+		return false
+	}
+	changes, ok := r.ps.Changes(req.Version)
+	if !ok {
+		return false
+	}
+
+	pg := r.newPage(proto.Size(res))
+	version := req.Version
+	for _, c := range changes {
+		// the changes recorded after the version of the response are sent next time
+		if c.Version > res.Version {
+			break
+		}
+		p, err := r.ps.GetPeer(c.Addr)
+		if err != nil || c.Removed {
+			if !pg.add(6, len(c.Addr)) {
+				res.More = true
+				break
+			}
+			res.Removed = append(res.Removed, c.Addr)
+		} else {
+			pb := peerToPbPeer(p)
+			if !pg.add(1, proto.Size(pb)) {
+				res.More = true
+				break
+			}
+			res.Peers = append(res.Peers, pb)
+		}
+		version = c.Version
+	}
+	if res.More {
+		res.Version = version
+	}
+	return true
+}
+
+// snapshot returns the peers sorted by address following the cursor of the request
+func (r *RpcService) snapshot(req *p2p_pb.GetPeersRequest, res *p2p_pb.GetPeersResponse) {
+	res.Full = true
+	peers := sortedPeers(r.ps.GetPeers(), req.Cursor)
+
+	pg := r.newPage(proto.Size(res))
+	for i, p := range peers {
+		pb := peerToPbPeer(p)
+		if !pg.add(1, proto.Size(pb)) {
+			res.Cursor = peers[i-1].Addr()
+			break
+		}
+		res.Peers = append(res.Peers, pb)
+	}
+}
+
+// sortedPeers returns the peers sorted by address following the cursor
+func sortedPeers(peers []*peer.Peer, cursor string) []*peer.Peer {
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Addr() < peers[j].Addr()
+	})
+	i := sort.Search(len(peers), func(i int) bool {
+		return peers[i].Addr() > cursor
+	})
+	return peers[i:]
+}
@@ -3,25 +3,37 @@ package rpc
 import (
 	"context"
 	"errors"
-	"strings"
 
+	"github.com/mr-shifu/grpc-p2p/config"
 	"github.com/mr-shifu/grpc-p2p/peer"
 	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 )
 
 type RpcService struct {
+	cfg    config.Exchange
 	ps     *peer.PeerService
 	logger zerolog.Logger
 
+	// done is closed when the service is stopped to end the peer watches
+	done chan struct{}
+
 	p2p_pb.UnimplementedPeerServiceServer
 }
 
-func NewRpcService(ps *peer.PeerService, logger zerolog.Logger) *RpcService {
+func NewRpcService(cfg config.Exchange, ps *peer.PeerService, logger zerolog.Logger) *RpcService {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = defaultPageSize
+	}
+	if cfg.MaxResponseSize <= 0 {
+		cfg.MaxResponseSize = defaultMaxResponseSize
+	}
+
 	return &RpcService{
+		cfg:    cfg,
 		ps:     ps,
+		done:   make(chan struct{}),
 		logger: logger,
 	}
 }
@@ -30,56 +42,63 @@ func (rs *RpcService) RegisterService(s grpc.ServiceRegistrar) {
 	p2p_pb.RegisterPeerServiceServer(s, rs)
 }
 
+// Start serves peer watches until ctx is done and ends them afterwards
+func (rs *RpcService) Start(ctx context.Context) error {
+	<-ctx.Done()
+	close(rs.done)
+	return nil
+}
+
 func (r *RpcService) GetPeers(ctx context.Context, req *p2p_pb.GetPeersRequest) (*p2p_pb.GetPeersResponse, error) {
-	peer, err := getPeerFromContext(ctx)
+	record, err := r.admit(ctx, req.Self)
 	if err != nil {
-		return nil, errors.New("failed to validate peer")
+		return nil, err
 	}
-	defer r.ps.AddPeer(peer)
-	
-	peers := r.ps.GetPeers()
-	pbPeers := peersToPbPeers(peers)
-	return &p2p_pb.GetPeersResponse{
-		Peers: pbPeers,
-	}, nil
-}
+	defer func() {
+		r.ps.PutRecord(record)
+		r.ps.MarkSeen(record.Addrs[0])
+	}()
 
-func getPeerFromContext(ctx context.Context) (*peer.Peer, error) {
-	md, _ := metadata.FromIncomingContext(ctx)
-	addrs := md.Get("addr")
-	if len(addrs) == 0 {
-		return nil, errors.New("peer address not found")
+	// the version is read first so that changes racing with the response are sent again
+	epoch, version := r.ps.Version()
+	res := &p2p_pb.GetPeersResponse{
+		Self:    peer.RecordToPb(r.ps.Self().Record),
+		Epoch:   epoch,
+		Version: version,
 	}
-
-	attrs := make(map[string]string)
-	for k, v := range md {
-		if strings.HasPrefix(k, "attr-") {
-			key := strings.TrimPrefix(k, "attr-")
-			attrs[key] = v[0]
-		}
+	if !r.deltas(req, res) {
+		r.snapshot(req, res)
 	}
+	return res, nil
+}
 
-	p := peer.NewPeer(addrs[0], attrs)
-
-	return p, nil
+// admit returns the verified record of the caller if the caller is allowed to get the peers
+func (r *RpcService) admit(ctx context.Context, self *p2p_pb.PeerRecord) (*peer.Record, error) {
+	// an isolated node only serves the members of its cluster and the gateways
+	record, err := r.ps.AdmitCaller(ctx, self)
+	if errors.Is(err, peer.ErrPeerNotAdmitted) {
+		return nil, errors.New("peer is not a member of the cluster")
+	}
+	if err != nil {
+		return nil, errors.New("failed to validate peer")
+	}
+	return record, nil
 }
 
-func peersToPbPeers(peers []*peer.Peer) []*p2p_pb.Peer {
-	var pbPeers []*p2p_pb.Peer
-	for _, peer := range peers {
-		var attrs []*p2p_pb.Attribute
-		for k, v := range peer.Attributes() {
-			attrs = append(attrs, &p2p_pb.Attribute{
-				Key:   k,
-				Value: v,
-			})
-		}
-		p := &p2p_pb.Peer{
-			Address:    peer.Addr(),
-			Attributes: attrs,
-			State:      peer.GetState().String(),
-		}
-		pbPeers = append(pbPeers, p)
+func peerToPbPeer(p *peer.Peer) *p2p_pb.Peer {
+	var attrs []*p2p_pb.Attribute
+	for k, v := range p.Attributes() {
+		attrs = append(attrs, &p2p_pb.Attribute{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return &p2p_pb.Peer{
+		Address:     p.Addr(),
+		Attributes:  attrs,
+		State:       p.GetState().String(),
+		Record:      peer.RecordToPb(p.Record),
+		ClusterName: p.ClusterName,
+		Name:        p.Name,
 	}
-	return pbPeers
 }
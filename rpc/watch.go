@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/peer"
+	p2p_pb "github.com/mr-shifu/grpc-p2p/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// watchSyncInterval is the interval of the checks for the events dropped at the end
+// of a burst, which no later event reveals
+const watchSyncInterval = 1 * time.Second
+
+// WatchPeers sends the peers of the peerstore followed by its events until the
+// caller or the service stops. Events dropped because the caller does not keep up
+// are replaced by the changes since the last event sent, or a new snapshot if the
+// changes are no longer known
+func (r *RpcService) WatchPeers(req *p2p_pb.WatchPeersRequest, stream p2p_pb.PeerService_WatchPeersServer) error {
+	ctx := stream.Context()
+	record, err := r.admit(ctx, req.Self)
+	if err != nil {
+		return err
+	}
+	r.ps.PutRecord(record)
+	r.ps.MarkSeen(record.Addrs[0])
+
+	// subscribe before the snapshot so that no event is missed in between
+	events := r.ps.Subscribe(ctx, peer.EventFilter{})
+	version, err := r.sendSnapshot(stream)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(watchSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.done:
+			return nil
+		case <-ticker.C:
+			if _, current := r.ps.Version(); current > version && len(events) == 0 {
+				if version, err = r.sendChanges(stream, version); err != nil {
+					return err
+				}
+			}
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			// the event is already part of the snapshot or the changes sent
+			if e.Version <= version {
+				continue
+			}
+			if e.Version != version+1 {
+				version, err = r.sendChanges(stream, version)
+			} else {
+				version = e.Version
+				err = stream.Send(&p2p_pb.WatchPeersResponse{
+					Version: e.Version,
+					Event: &p2p_pb.PeerEvent{
+						Type: e.Type.String(),
+						Peer: peerToPbPeer(e.Peer),
+						Time: e.Time.UnixNano(),
+					},
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendSnapshot sends the peers of the peerstore in pages and returns the version
+// of the snapshot
+func (r *RpcService) sendSnapshot(stream p2p_pb.PeerService_WatchPeersServer) (uint64, error) {
+	epoch, version := r.ps.Version()
+	res := &p2p_pb.WatchPeersResponse{
+		Self:    peer.RecordToPb(r.ps.Self().Record),
+		Epoch:   epoch,
+		Version: version,
+	}
+
+	pg := r.newPage(proto.Size(res))
+	for _, p := range sortedPeers(r.ps.GetPeers(), "") {
+		pb := peerToPbPeer(p)
+		if !pg.add(4, proto.Size(pb)) {
+			if err := stream.Send(res); err != nil {
+				return 0, err
+			}
+			res = &p2p_pb.WatchPeersResponse{Epoch: epoch, Version: version}
+			pg = r.newPage(proto.Size(res))
+			pg.add(4, proto.Size(pb))
+		}
+		res.Snapshot = append(res.Snapshot, pb)
+	}
+	res.Synced = true
+	return version, stream.Send(res)
+}
+
+// sendChanges sends the latest change of every peer changed since version as an
+// event and returns the version of the changes sent
+func (r *RpcService) sendChanges(stream p2p_pb.PeerService_WatchPeersServer, version uint64) (uint64, error) {
+	_, current := r.ps.Version()
+	changes, ok := r.ps.Changes(version)
+	if !ok {
+		return r.sendSnapshot(stream)
+	}
+
+	now := r.ps.Clock().Now().UnixNano()
+	for _, c := range changes {
+		if c.Version > current {
+			break
+		}
+		event := &p2p_pb.PeerEvent{Time: now}
+		if p, err := r.ps.GetPeer(c.Addr); err == nil && !c.Removed {
+			event.Type = peer.PeerUpdated.String()
+			event.Peer = peerToPbPeer(p)
+		} else {
+			event.Type = peer.PeerRemoved.String()
+			event.Peer = &p2p_pb.Peer{Address: c.Addr}
+		}
+		if err := stream.Send(&p2p_pb.WatchPeersResponse{Version: c.Version, Event: event}); err != nil {
+			return 0, err
+		}
+	}
+	return current, nil
+}
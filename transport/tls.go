@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	ErrInvalidCABundle = errors.New("transport: no certificates found in CA bundle")
+)
+
+// ServerCredentials returns the transport credentials used by the node server.
+// It returns insecure credentials if TLS is not enabled
+func ServerCredentials(cfg config.TLS) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.RequireClientCert {
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.ClientCAs = pool
+	}
+
+	return credentials.NewTLS(tc), nil
+}
+
+// ClientCredentials returns the transport credentials used to dial peers.
+// The node certificate is presented as client certificate so that peers requiring
+// client certificates accept the connection
+func ClientCredentials(cfg config.TLS) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = pool
+	}
+
+	return credentials.NewTLS(tc), nil
+}
+
+// ServerOption returns the grpc server option applying the server credentials
+func ServerOption(cfg config.TLS) (grpc.ServerOption, error) {
+	creds, err := ServerCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Creds(creds), nil
+}
+
+// DialOption returns the grpc dial option applying the client credentials
+func DialOption(cfg config.TLS) (grpc.DialOption, error) {
+	creds, err := ClientCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithTransportCredentials(creds), nil
+}
+
+// PeerCertificate returns the verified certificate of the remote peer of an incoming rpc.
+// It returns false if the connection is not secured by TLS or the peer did not present
+// a verified certificate
+func PeerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, false
+	}
+	if len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return info.State.VerifiedChains[0][0], true
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, ErrInvalidCABundle
+	}
+	return pool, nil
+}
@@ -0,0 +1,207 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mr-shifu/grpc-p2p/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testCA issues throwaway certificates written to a temporary directory
+type testCA struct {
+	dir    string
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &testCA{dir: t.TempDir(), cert: cert, key: key, serial: 1}
+	writePEM(t, filepath.Join(ca.dir, "ca.pem"), "CERTIFICATE", der)
+	return ca
+}
+
+// issue returns a TLS config presenting a certificate for the given IP addresses and DNS names
+func (ca *testCA) issue(t *testing.T, name string, ips []string, dnsNames []string) config.TLS {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, ip := range ips {
+		tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(ip))
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.TLS{
+		CAFile:   filepath.Join(ca.dir, "ca.pem"),
+		CertFile: filepath.Join(ca.dir, name+".pem"),
+		KeyFile:  filepath.Join(ca.dir, name+"-key.pem"),
+	}
+	writePEM(t, cfg.CertFile, "CERTIFICATE", der)
+	writePEM(t, cfg.KeyFile, "PRIVATE KEY", keyDer)
+	return cfg
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve starts a server secured by cfg on loopback and returns its address and the
+// certificates presented by the clients of the served rpcs
+func serve(t *testing.T, cfg config.TLS) (string, <-chan *x509.Certificate) {
+	t.Helper()
+
+	opt, err := ServerOption(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs := make(chan *x509.Certificate, 1)
+	srv := grpc.NewServer(opt, grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		cert, _ := PeerCertificate(ctx)
+		certs <- cert
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	return ln.Addr().String(), certs
+}
+
+// check calls the health service at addr with the client credentials of cfg
+func check(t *testing.T, addr string, cfg config.TLS) error {
+	t.Helper()
+
+	opt, err := DialOption(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(addr, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestTLSAcceptsVerifiedClient(t *testing.T) {
+	ca := newTestCA(t)
+	server := ca.issue(t, "server", []string{"127.0.0.1"}, nil)
+	server.RequireClientCert = true
+	addr, certs := serve(t, server)
+
+	if err := check(t, addr, ca.issue(t, "client", []string{"127.0.0.1"}, nil)); err != nil {
+		t.Fatalf("verified client rejected: %v", err)
+	}
+	cert := <-certs
+	if cert == nil || cert.Subject.CommonName != "client" {
+		t.Fatalf("expected the verified client certificate, got %v", cert)
+	}
+}
+
+func TestTLSRejectsClientWithoutCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	server := ca.issue(t, "server", []string{"127.0.0.1"}, nil)
+	server.RequireClientCert = true
+	addr, _ := serve(t, server)
+
+	// the client trusts the CA but does not present a certificate
+	pool, err := loadCertPool(server.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool}))
+	conn, err := grpc.Dial(addr, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+		t.Fatal("client without certificate accepted")
+	}
+}
+
+func TestTLSRejectsHostnameMismatch(t *testing.T) {
+	ca := newTestCA(t)
+	// the server certificate does not cover the loopback address dialed by the client
+	addr, _ := serve(t, ca.issue(t, "server", nil, []string{"other.example"}))
+
+	if err := check(t, addr, ca.issue(t, "client", []string{"127.0.0.1"}, nil)); err == nil {
+		t.Fatal("server certificate for another host accepted")
+	}
+}